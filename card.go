@@ -0,0 +1,186 @@
+package paytring
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CardBrand is the network of a card as told by its number.
+type CardBrand string
+
+const (
+	CardUnknown    CardBrand = ""
+	CardVisa       CardBrand = "visa"
+	CardMastercard CardBrand = "mastercard"
+	CardRuPay      CardBrand = "rupay"
+	CardAmex       CardBrand = "amex"
+	CardDiners     CardBrand = "diners"
+)
+
+// cardRange is a span of number prefixes, both ends included, that belong
+// to a brand. from and to have the same number of digits.
+type cardRange struct {
+	from, to string
+	brand    CardBrand
+}
+
+// cardRanges is checked in order, the first match wins. RuPay only owns
+// parts of the 60 and 65 prefixes, the rest belongs to Discover and other
+// networks the SDK does not tell apart.
+var cardRanges = []cardRange{
+	{"34", "34", CardAmex},
+	{"37", "37", CardAmex},
+	{"508500", "508999", CardRuPay},
+	{"606985", "607984", CardRuPay},
+	{"608001", "608500", CardRuPay},
+	{"652150", "653149", CardRuPay},
+	{"817200", "820199", CardRuPay},
+	{"300", "305", CardDiners},
+	{"36", "36", CardDiners},
+	{"38", "39", CardDiners},
+	{"4", "4", CardVisa},
+	{"51", "55", CardMastercard},
+	{"2221", "2720", CardMastercard},
+}
+
+// Card numbers of any brand are between minCardLength and maxCardLength
+// digits long.
+const (
+	minCardLength = 12
+	maxCardLength = 19
+)
+
+// cardLengths lists the number lengths each brand issues.
+var cardLengths = map[CardBrand][]int{
+	CardVisa:       {13, 16, 19},
+	CardMastercard: {16},
+	CardRuPay:      {16},
+	CardAmex:       {15},
+	CardDiners:     {14, 15, 16, 17, 18, 19},
+}
+
+// CVVLength returns the number of digits of the brand's security code.
+func (b CardBrand) CVVLength() int {
+	if b == CardAmex {
+		return 4
+	}
+	return 3
+}
+
+// DetectCardBrand tells the brand of a card from the prefix of its number.
+// Spaces and dashes in number are ignored.
+func DetectCardBrand(number string) CardBrand {
+	digits := cardDigits(number)
+	for _, r := range cardRanges {
+		if len(digits) < len(r.from) {
+			continue
+		}
+		prefix := digits[:len(r.from)]
+		if prefix >= r.from && prefix <= r.to {
+			return r.brand
+		}
+	}
+	return CardUnknown
+}
+
+// LuhnValid reports whether number passes the Luhn checksum. Spaces and
+// dashes in number are ignored.
+func LuhnValid(number string) bool {
+	digits := cardDigits(number)
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ValidateCardData checks the card fields of data the way ProcessOrder does
+// before sending them: a Luhn check, a number length and CVV length that
+// fit the brand, and an expiry that has not passed at now. Cards of brands
+// DetectCardBrand does not know, such as Maestro or JCB, only get the Luhn
+// check and the lengths any card may have. The returned
+// error is a *ValidationError keyed by card.number, card.expiry_month,
+// card.expiry_year and card.cvv.
+func ValidateCardData(data PaymentData, now time.Time) error {
+	verr := &ValidationError{}
+
+	number := cardDigits(data.CardNumber)
+	brand := DetectCardBrand(number)
+	switch {
+	case number == "":
+		verr.add("card.number", "is required")
+	case strings.Trim(number, "0123456789") != "":
+		verr.add("card.number", "must only contain digits")
+	case brand == CardUnknown && (len(number) < minCardLength || len(number) > maxCardLength):
+		verr.add("card.number", "must be %d to %d digits", minCardLength, maxCardLength)
+	case brand != CardUnknown && !validCardLength(brand, len(number)):
+		verr.add("card.number", "has the wrong length for %s cards", brand)
+	case !LuhnValid(number):
+		verr.add("card.number", "is not a valid card number")
+	}
+
+	month, monthErr := strconv.Atoi(data.ExpiryMonth)
+	switch {
+	case data.ExpiryMonth == "":
+		verr.add("card.expiry_month", "is required")
+	case monthErr != nil || month < 1 || month > 12:
+		verr.add("card.expiry_month", "must be between 01 and 12")
+	}
+
+	year, yearErr := strconv.Atoi(data.ExpiryYear)
+	if yearErr == nil && len(data.ExpiryYear) == 2 {
+		year += now.Year() / 100 * 100
+	}
+	switch {
+	case data.ExpiryYear == "":
+		verr.add("card.expiry_year", "is required")
+	case yearErr != nil || (len(data.ExpiryYear) != 2 && len(data.ExpiryYear) != 4):
+		verr.add("card.expiry_year", "must be a two or four digit year")
+	case year > now.Year()+20:
+		verr.add("card.expiry_year", "is too far in the future")
+	case year < now.Year() || (year == now.Year() && verr.FieldError("card.expiry_month") == "" && month < int(now.Month())):
+		verr.add("card.expiry_year", "card has expired")
+	}
+
+	switch {
+	case data.Cvv == "":
+		verr.add("card.cvv", "is required")
+	case strings.Trim(data.Cvv, "0123456789") != "":
+		verr.add("card.cvv", "must only contain digits")
+	case brand != CardUnknown && len(data.Cvv) != brand.CVVLength():
+		verr.add("card.cvv", "must be %d digits for %s cards", brand.CVVLength(), brand)
+	case len(data.Cvv) != 3 && len(data.Cvv) != 4:
+		verr.add("card.cvv", "must be 3 or 4 digits")
+	}
+
+	return verr.err()
+}
+
+func validCardLength(brand CardBrand, length int) bool {
+	for _, l := range cardLengths[brand] {
+		if l == length {
+			return true
+		}
+	}
+	return false
+}
+
+// cardDigits strips the spaces and dashes card numbers are often written
+// with.
+func cardDigits(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
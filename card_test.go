@@ -0,0 +1,85 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCardBrand(t *testing.T) {
+	cases := map[string]CardBrand{
+		"4111 1111 1111 1111": CardVisa,
+		"5555555555554444":    CardMastercard,
+		"2223003122003222":    CardMastercard,
+		"378282246310005":     CardAmex,
+		"36227206271667":      CardDiners,
+		"6521501111111112":    CardRuPay,
+		"6070001111111118":    CardRuPay,
+		"8172001111111111":    CardRuPay,
+		"5085000000000007":    CardRuPay,
+		"6011111111111117":    CardUnknown, // Discover
+		"3530111333300000":    CardUnknown, // JCB
+		"9999999999999995":    CardUnknown,
+	}
+
+	for number, brand := range cases {
+		assert.Equal(t, brand, DetectCardBrand(number), number)
+		if brand != CardUnknown {
+			assert.True(t, LuhnValid(number), number)
+		}
+	}
+	assert.False(t, LuhnValid("4111111111111112"))
+}
+
+func TestValidateCardData(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ValidateCardData(PaymentData{CardNumber: "4111-1111-1111-1111", ExpiryMonth: "03", ExpiryYear: "26", Cvv: "123"}, now))
+	assert.NoError(t, ValidateCardData(PaymentData{CardNumber: "378282246310005", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "1234"}, now))
+
+	var verr *ValidationError
+	err := ValidateCardData(PaymentData{CardNumber: "4111111111111112", ExpiryMonth: "02", ExpiryYear: "2026", Cvv: "12"}, now)
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "is not a valid card number", verr.FieldError("card.number"))
+	assert.Equal(t, "card has expired", verr.FieldError("card.expiry_year"))
+	assert.Equal(t, "must be 3 digits for visa cards", verr.FieldError("card.cvv"))
+
+	err = ValidateCardData(PaymentData{CardNumber: "37828224631000", ExpiryMonth: "13", ExpiryYear: "2099", Cvv: "123"}, now)
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "has the wrong length for amex cards", verr.FieldError("card.number"))
+	assert.Equal(t, "must be between 01 and 12", verr.FieldError("card.expiry_month"))
+	assert.Equal(t, "is too far in the future", verr.FieldError("card.expiry_year"))
+	assert.Equal(t, "must be 4 digits for amex cards", verr.FieldError("card.cvv"))
+
+	// Brands the SDK does not know are still accepted.
+	for _, number := range []string{"6759649826438453", "3530111333300000", "6011111111111117"} {
+		assert.NoError(t, ValidateCardData(PaymentData{CardNumber: number, ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "123"}, now), number)
+	}
+	err = ValidateCardData(PaymentData{CardNumber: "6011111111111118", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "12345"}, now)
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "is not a valid card number", verr.FieldError("card.number"))
+	assert.Equal(t, "must be 3 or 4 digits", verr.FieldError("card.cvv"))
+	err = ValidateCardData(PaymentData{CardNumber: "60111111117", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "123"}, now)
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "must be 12 to 19 digits", verr.FieldError("card.number"))
+
+	err = ValidateCardData(PaymentData{}, now)
+	assert.True(t, errors.As(err, &verr))
+	for _, field := range []string{"card.number", "card.expiry_month", "card.expiry_year", "card.cvv"} {
+		assert.Equal(t, "is required", verr.FieldError(field), field)
+	}
+}
+
+func TestProcessOrderValidatesCardLocally(t *testing.T) {
+	client := NewClient(apiKey, apiSecret, WithBaseURL("http://127.0.0.1:0"), WithClock(func() time.Time {
+		return time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}))
+
+	_, err := client.ProcessOrder("TEST_ORDER_ID_PROCESS", "card", "", PaymentData{CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "123"}, "")
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "card has expired", verr.FieldError("card.expiry_year"))
+}
@@ -0,0 +1,263 @@
+package paytring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CustomerRequest describes a customer to create with CreateCustomer or the
+// changes to make with UpdateCustomer, which leaves blank fields as they
+// are.
+type CustomerRequest struct {
+	Name  string
+	Email string
+	Phone string
+	Notes *Notes
+}
+
+// Validate checks the request as CreateCustomer would. The returned error
+// is a *ValidationError listing every problem found.
+func (r *CustomerRequest) Validate() error {
+	return r.validate(true)
+}
+
+func (r *CustomerRequest) validate(create bool) error {
+	verr := &ValidationError{}
+
+	if create && r.Name == "" {
+		verr.add("name", "is required")
+	}
+	if create && r.Email == "" && r.Phone == "" {
+		verr.add("phone", "or email is required")
+	}
+	if !create && r.Name == "" && r.Email == "" && r.Phone == "" && r.Notes == nil {
+		verr.add("customer", "nothing to update")
+	}
+	if r.Email != "" && !strings.Contains(r.Email, "@") {
+		verr.add("email", "must be an email address")
+	}
+	if r.Phone != "" && strings.Trim(r.Phone, "+0123456789 ") != "" {
+		verr.add("phone", "must only contain digits")
+	}
+
+	return verr.err()
+}
+
+// body builds the create or update customer payload for key.
+func (r *CustomerRequest) body(key string) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"key":  key,
+		"hash": "none",
+	}
+
+	addToMapIfNotBlank(requestBody, "cname", r.Name)
+	addToMapIfNotBlank(requestBody, "email", r.Email)
+	addToMapIfNotBlank(requestBody, "phone", r.Phone)
+
+	if notes := notesMap(r.Notes); len(notes) > 0 {
+		requestBody["notes"] = notes
+	}
+
+	return requestBody
+}
+
+// InstrumentType is the kind of a saved payment instrument.
+type InstrumentType string
+
+const (
+	// InstrumentCard is a saved card, kept as a token from CreateCardToken.
+	InstrumentCard InstrumentType = "card"
+	InstrumentUPI  InstrumentType = "upi"
+)
+
+// Instrument is a payment instrument saved for a customer: a card token or
+// a VPA.
+type Instrument struct {
+	InstrumentId string         `json:"instrument_id"`
+	Type         InstrumentType `json:"type"`
+	TokenId      string         `json:"token_id"`
+	Vpa          string         `json:"vpa"`
+	// Brand and Last4 describe saved cards.
+	Brand CardBrand `json:"brand"`
+	Last4 string    `json:"last4"`
+}
+
+// CustomerProfile is a customer as returned by CreateCustomer and friends.
+type CustomerProfile struct {
+	CustomerId        string            `json:"customer_id"`
+	Name              string            `json:"name"`
+	Email             string            `json:"email"`
+	Phone             string            `json:"phone"`
+	BillingAddresses  []BillingAddress  `json:"billing_addresses"`
+	ShippingAddresses []ShippingAddress `json:"shipping_addresses"`
+	Instruments       []Instrument      `json:"instruments"`
+	Notes             Notes             `json:"notes"`
+	CreatedAt         string            `json:"created_at"`
+	Response
+}
+
+// Customer returns a reference to p to pass to CreateOrder.
+func (p *CustomerProfile) Customer() Customer {
+	return Customer{Id: p.CustomerId}
+}
+
+func (c *Api) CreateCustomer(req *CustomerRequest) (*CustomerProfile, error) {
+	return c.CreateCustomerCtx(context.Background(), req)
+}
+
+// CreateCustomerCtx validates req and creates the customer. Its CustomerId
+// stays the same for as long as the customer exists.
+func (c *Api) CreateCustomerCtx(ctx context.Context, req *CustomerRequest) (*CustomerProfile, error) {
+
+	if err := req.validate(true); err != nil {
+		return nil, err
+	}
+
+	return c.customerCall(ctx, "CreateCustomer", "v2/customer/create", req.body(c.ApiKey))
+}
+
+func (c *Api) UpdateCustomer(customerId string, req *CustomerRequest) (*CustomerProfile, error) {
+	return c.UpdateCustomerCtx(context.Background(), customerId, req)
+}
+
+// UpdateCustomerCtx changes the fields set in req and leaves the others as
+// they are.
+func (c *Api) UpdateCustomerCtx(ctx context.Context, customerId string, req *CustomerRequest) (*CustomerProfile, error) {
+
+	if err := req.validate(false); err != nil {
+		return nil, err
+	}
+
+	requestBody := req.body(c.ApiKey)
+	requestBody["id"] = customerId
+
+	return c.customerCall(ctx, "UpdateCustomer", "v2/customer/update", requestBody)
+}
+
+func (c *Api) FetchCustomer(customerId string) (*CustomerProfile, error) {
+	return c.FetchCustomerCtx(context.Background(), customerId)
+}
+
+func (c *Api) FetchCustomerCtx(ctx context.Context, customerId string) (*CustomerProfile, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   customerId,
+		"hash": "none",
+	}
+
+	return c.customerCall(ctx, "FetchCustomer", "v2/customer/fetch", requestBody)
+}
+
+func (c *Api) AddBillingAddress(customerId string, address BillingAddress) (*CustomerProfile, error) {
+	return c.AddBillingAddressCtx(context.Background(), customerId, address)
+}
+
+// AddBillingAddressCtx saves address for the customer.
+func (c *Api) AddBillingAddressCtx(ctx context.Context, customerId string, address BillingAddress) (*CustomerProfile, error) {
+	return c.addAddress(ctx, "AddBillingAddress", customerId, "billing", address)
+}
+
+func (c *Api) AddShippingAddress(customerId string, address ShippingAddress) (*CustomerProfile, error) {
+	return c.AddShippingAddressCtx(context.Background(), customerId, address)
+}
+
+// AddShippingAddressCtx saves address for the customer.
+func (c *Api) AddShippingAddressCtx(ctx context.Context, customerId string, address ShippingAddress) (*CustomerProfile, error) {
+	return c.addAddress(ctx, "AddShippingAddress", customerId, "shipping", BillingAddress(address))
+}
+
+func (c *Api) addAddress(ctx context.Context, name string, customerId string, addressType string, address BillingAddress) (*CustomerProfile, error) {
+
+	verr := &ValidationError{}
+	if address.Line1 == "" {
+		verr.add("address.line1", "is required")
+	}
+	if address.Zipcode == "" {
+		verr.add("address.zipcode", "is required")
+	}
+	if err := verr.err(); err != nil {
+		return nil, err
+	}
+
+	requestBody := map[string]interface{}{
+		"key":     c.ApiKey,
+		"id":      customerId,
+		"type":    addressType,
+		"address": addressMap(address),
+		"hash":    "none",
+	}
+
+	return c.customerCall(ctx, name, "v2/customer/address/add", requestBody)
+}
+
+func (c *Api) AttachInstrument(customerId string, instrument Instrument) (*CustomerProfile, error) {
+	return c.AttachInstrumentCtx(context.Background(), customerId, instrument)
+}
+
+// AttachInstrumentCtx saves instrument for the customer: a card by the
+// TokenId CreateCardToken returned, or a UPI Vpa.
+func (c *Api) AttachInstrumentCtx(ctx context.Context, customerId string, instrument Instrument) (*CustomerProfile, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   customerId,
+		"type": string(instrument.Type),
+		"hash": "none",
+	}
+
+	verr := &ValidationError{}
+	switch instrument.Type {
+	case InstrumentCard:
+		if instrument.TokenId == "" {
+			verr.add("token_id", "is required for a card")
+		}
+		requestBody["token_id"] = instrument.TokenId
+	case InstrumentUPI:
+		if !strings.Contains(instrument.Vpa, "@") {
+			verr.add("vpa", "must look like name@bank")
+		}
+		requestBody["vpa"] = instrument.Vpa
+	default:
+		verr.add("type", "must be %s or %s", InstrumentCard, InstrumentUPI)
+	}
+	if err := verr.err(); err != nil {
+		return nil, err
+	}
+
+	return c.customerCall(ctx, "AttachInstrument", "v2/customer/instrument/add", requestBody)
+}
+
+func (c *Api) DetachInstrument(customerId string, instrumentId string) (*CustomerProfile, error) {
+	return c.DetachInstrumentCtx(context.Background(), customerId, instrumentId)
+}
+
+// DetachInstrumentCtx removes a saved instrument from the customer. A card
+// token stays usable until it is deleted with DeleteCardToken.
+func (c *Api) DetachInstrumentCtx(ctx context.Context, customerId string, instrumentId string) (*CustomerProfile, error) {
+
+	requestBody := map[string]interface{}{
+		"key":           c.ApiKey,
+		"id":            customerId,
+		"instrument_id": instrumentId,
+		"hash":          "none",
+	}
+
+	return c.customerCall(ctx, "DetachInstrument", "v2/customer/instrument/remove", requestBody)
+}
+
+func (c *Api) customerCall(ctx context.Context, name string, endpoint string, requestBody map[string]interface{}) (*CustomerProfile, error) {
+
+	body, response, err := c.post(ctx, name, endpoint, c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var customer CustomerProfile
+	if err := decodeResponse(body, "customer", response, &customer); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for %s: %w", name, err)
+	}
+
+	return &customer, nil
+}
@@ -0,0 +1,98 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerRequestValidate(t *testing.T) {
+	var verr *ValidationError
+	assert.True(t, errors.As((&CustomerRequest{Email: "john.doe"}).Validate(), &verr))
+	assert.Equal(t, "is required", verr.FieldError("name"))
+	assert.Equal(t, "must be an email address", verr.FieldError("email"))
+
+	assert.True(t, errors.As((&CustomerRequest{}).validate(false), &verr))
+	assert.Equal(t, "nothing to update", verr.FieldError("customer"))
+
+	assert.NoError(t, (&CustomerRequest{Name: "John Doe", Phone: "+91 9876543210"}).Validate())
+}
+
+func TestOrderForSavedCustomer(t *testing.T) {
+	client, server := newTestClient(t)
+
+	profile, err := client.CreateCustomer(&CustomerRequest{Name: "John Doe", Email: "john.doe@example.com", Phone: "9876543210"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, profile.CustomerId)
+
+	profile, err = client.UpdateCustomer(profile.CustomerId, &CustomerRequest{Phone: "9123456780"})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", profile.Name)
+	assert.Equal(t, "9123456780", profile.Phone)
+
+	body := NewOrder(NewMoney(1000, "INR"), "TEST_RECEIPT_CUSTOMER").WithCustomerId(profile.CustomerId).body("test_key")
+	assert.Equal(t, profile.CustomerId, body["customer_id"])
+	assert.NotContains(t, body, "cname")
+
+	created, err := client.CreateOrder(NewMoney(1000, "INR"), "TEST_RECEIPT_CUSTOMER", "https://example.com/callback", profile.Customer())
+	assert.NoError(t, err)
+
+	order, err := client.FetchOrder(created.OrderId, "normal")
+	assert.NoError(t, err)
+	assert.Equal(t, profile.CustomerId, order.Customer.Id)
+	assert.Equal(t, "john.doe@example.com", order.Customer.Email)
+	assert.Equal(t, "9123456780", order.Customer.Phone)
+
+	_, err = client.CreateOrder(NewMoney(1000, "INR"), "TEST_RECEIPT_UNKNOWN", "https://example.com/callback", Customer{Id: "cust_missing"})
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	_, stored := server.Customer("cust_missing")
+	assert.False(t, stored)
+}
+
+func TestCustomerAddressesAndInstruments(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	profile, err := client.CreateCustomer(&CustomerRequest{Name: "John Doe", Phone: "9876543210"})
+	assert.NoError(t, err)
+
+	_, err = client.AddBillingAddress(profile.CustomerId, BillingAddress{City: "Mumbai"})
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "is required", verr.FieldError("address.line1"))
+	_, err = client.AddShippingAddress(profile.CustomerId, ShippingAddress{Line1: "2 MG Road"})
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "is required", verr.FieldError("address.zipcode"))
+
+	_, err = client.AddBillingAddress(profile.CustomerId, BillingAddress{Line1: "1 MG Road", City: "Mumbai", Zipcode: "400001"})
+	assert.NoError(t, err)
+	profile, err = client.AddShippingAddress(profile.CustomerId, ShippingAddress{Line1: "2 MG Road", City: "Pune", Zipcode: "411001"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1 MG Road", profile.BillingAddresses[0].Line1)
+	assert.Equal(t, "Pune", profile.ShippingAddresses[0].City)
+
+	token, err := client.CreateCardToken(&TokenRequest{
+		CustomerId: profile.CustomerId,
+		Card:       PaymentData{CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2040", Cvv: "123"},
+		Consent:    true,
+	})
+	assert.NoError(t, err)
+
+	_, err = client.AttachInstrument(profile.CustomerId, Instrument{Type: InstrumentCard, TokenId: token.TokenId})
+	assert.NoError(t, err)
+	profile, err = client.AttachInstrument(profile.CustomerId, Instrument{Type: InstrumentUPI, Vpa: "johndoe@okbank"})
+	assert.NoError(t, err)
+	if assert.Len(t, profile.Instruments, 2) {
+		assert.Equal(t, "1111", profile.Instruments[0].Last4)
+		assert.Equal(t, CardVisa, profile.Instruments[0].Brand)
+	}
+
+	profile, err = client.DetachInstrument(profile.CustomerId, profile.Instruments[0].InstrumentId)
+	assert.NoError(t, err)
+	assert.Len(t, profile.Instruments, 1)
+
+	profile, err = client.FetchCustomer(profile.CustomerId)
+	assert.NoError(t, err)
+	assert.Equal(t, InstrumentUPI, profile.Instruments[0].Type)
+}
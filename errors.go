@@ -0,0 +1,177 @@
+package paytring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// APIError is returned when Paytring rejects a request. Retrieve it with
+// errors.As to inspect the failure:
+//
+//	var apiErr *paytring.APIError
+//	if errors.As(err, &apiErr) {
+//		for field, messages := range apiErr.Fields {
+//			// show messages next to field
+//		}
+//	}
+type APIError struct {
+	// StatusCode is the HTTP status of the response, or 0 when the error
+	// was built by HandleResponse from an already decoded body.
+	StatusCode int
+	// Code is Paytring's error code, if the response carried one.
+	Code string
+	// Message is a human readable description of the failure. For
+	// validation failures it is the first message of the first field.
+	Message string
+	// Fields maps request field names to their validation messages.
+	Fields map[string][]string
+	// RequestID identifies the request on Paytring's side, when known.
+	RequestID string
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	return Redact(e.Message)
+}
+
+// FieldError returns the first validation message for field, or "".
+func (e *APIError) FieldError(field string) string {
+	if messages := e.Fields[field]; len(messages) > 0 {
+		return messages[0]
+	}
+	return ""
+}
+
+func parseAPIError(errors interface{}) *APIError {
+	apiErr := &APIError{}
+
+	if errors == nil {
+		apiErr.Message = "Something went wrong, invalid response received"
+		return apiErr
+	}
+	errorJSON, err := json.Marshal(errors)
+	if err != nil {
+		apiErr.Message = fmt.Sprintf("Unexpected error response recieved: %v", err)
+		return apiErr
+	}
+	var errorMap map[string]interface{}
+	if err := json.Unmarshal(errorJSON, &errorMap); err != nil {
+		apiErr.Message = fmt.Sprintf("Invalid error response recieved: %v", err)
+		return apiErr
+	}
+
+	if code, ok := errorMap["code"]; ok && code != nil {
+		apiErr.Code = fmt.Sprint(code)
+	}
+
+	message := errorMap["message"]
+
+	if message == nil {
+		apiErr.Message = "Something went wrong, invalid data"
+		return apiErr
+	}
+
+	// The message is either plain text or a map of field names to
+	// messages, which Paytring sends JSON encoded inside a string.
+	messageMap, ok := message.(map[string]interface{})
+	if !ok {
+		s, isString := message.(string)
+		if !isString || json.Unmarshal([]byte(s), &messageMap) != nil {
+			apiErr.Message = fmt.Sprint(message)
+			return apiErr
+		}
+	}
+
+	apiErr.Fields = make(map[string][]string, len(messageMap))
+	for field, v := range messageMap {
+		switch v := v.(type) {
+		case string:
+			apiErr.Fields[field] = []string{v}
+		case []interface{}:
+			for _, m := range v {
+				if s, ok := m.(string); ok {
+					apiErr.Fields[field] = append(apiErr.Fields[field], s)
+				}
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(apiErr.Fields))
+	for field := range apiErr.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if m := apiErr.FieldError(field); m != "" {
+			apiErr.Message = m
+			return apiErr
+		}
+	}
+
+	apiErr.Message = "Something went wrong, please try again later."
+	return apiErr
+}
+
+// ValidationError is returned when a request is rejected locally, before
+// anything is sent to Paytring. Like APIError.Fields, Fields maps request
+// field names to messages.
+type ValidationError struct {
+	Fields map[string][]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		for _, m := range e.Fields[field] {
+			messages = append(messages, field+": "+m)
+		}
+	}
+	return Redact("invalid request: " + strings.Join(messages, "; "))
+}
+
+// FieldError returns the first message for field, or "".
+func (e *ValidationError) FieldError(field string) string {
+	if messages := e.Fields[field]; len(messages) > 0 {
+		return messages[0]
+	}
+	return ""
+}
+
+func (e *ValidationError) add(field string, format string, args ...interface{}) {
+	if e.Fields == nil {
+		e.Fields = map[string][]string{}
+	}
+	e.Fields[field] = append(e.Fields[field], fmt.Sprintf(format, args...))
+}
+
+// merge adds the fields of err to e when err is a *ValidationError.
+func (e *ValidationError) merge(err error) {
+	var other *ValidationError
+	if !errors.As(err, &other) {
+		return
+	}
+	for field, messages := range other.Fields {
+		for _, m := range messages {
+			e.add(field, "%s", m)
+		}
+	}
+}
+
+// err returns e if any field failed, nil otherwise.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
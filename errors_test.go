@@ -0,0 +1,65 @@
+package paytring
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIErrorFields(t *testing.T) {
+	apiErr := parseAPIError(map[string]interface{}{
+		"code":    422,
+		"message": `{"phone":["The phone must be 10 digits."],"email":["The email is invalid.","The email is required."]}`,
+	})
+
+	assert.Equal(t, "422", apiErr.Code)
+	assert.Equal(t, "The email is invalid.", apiErr.Message)
+	assert.Equal(t, []string{"The email is invalid.", "The email is required."}, apiErr.Fields["email"])
+	assert.Equal(t, "The phone must be 10 digits.", apiErr.FieldError("phone"))
+}
+
+func TestParseAPIErrorPlainMessage(t *testing.T) {
+	apiErr := parseAPIError(map[string]interface{}{"message": "Order not found"})
+	assert.Equal(t, "Order not found", apiErr.Error())
+	assert.Empty(t, apiErr.Fields)
+
+	assert.Equal(t, "Something went wrong, invalid response received", parseAPIError(nil).Error())
+}
+
+func TestAPIErrorFromResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_123")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"status":false,"error":{"code":"E_VALIDATION","message":"{\"amount\":[\"The amount field is required.\"]}"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+	_, err := client.PartialRefund("TEST_ORDER_ID_PARTIAL_REFUND", NewMoney(0, "INR"))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, "E_VALIDATION", apiErr.Code)
+	assert.Equal(t, "req_123", apiErr.RequestID)
+	assert.Equal(t, "The amount field is required.", apiErr.Error())
+	assert.Contains(t, string(apiErr.Body), "E_VALIDATION")
+}
+
+func TestAPIErrorForNonJSONBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>Bad Gateway</html>"))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+	_, err := client.FetchRefund("TEST_REFUND_ID_FETCH")
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+}
@@ -1,6 +1,6 @@
 module github.com/paytring/go-sdk
 
-go 1.20
+go 1.21
 
 require (
 	github.com/go-resty/resty/v2 v2.7.0
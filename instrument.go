@@ -0,0 +1,90 @@
+package paytring
+
+import (
+	"context"
+	"time"
+)
+
+// Instrumentation observes every API call the client makes, e.g. to record
+// traces and metrics. The paytringotel module provides an OpenTelemetry
+// implementation.
+type Instrumentation interface {
+	// StartCall is called before the first attempt of call. The returned
+	// context is used for the call, so a span started here is the parent
+	// of the HTTP request. end is called once with the outcome after the
+	// last attempt.
+	StartCall(ctx context.Context, call CallInfo) (_ context.Context, end func(CallResult))
+}
+
+// CallInfo describes an API call about to be made.
+type CallInfo struct {
+	// Method is the SDK method, e.g. "CreateOrder".
+	Method string
+	// Endpoint is the Paytring endpoint, e.g. "v2/order/create".
+	Endpoint string
+	// OrderId is the order the call is about, when the request names one.
+	OrderId string
+}
+
+// CallResult is the outcome of an API call.
+type CallResult struct {
+	// StatusCode is the HTTP status of the last attempt, or 0 when no
+	// response was received.
+	StatusCode int
+	// OrderId is the order the call was about, which for CreateOrder is
+	// only known from the response.
+	OrderId string
+	// Attempts is the number of requests sent, so Attempts-1 were retries.
+	Attempts int
+	// Duration covers every attempt and the backoff between them.
+	Duration time.Duration
+	// Err is the error returned to the caller, if any.
+	Err error
+}
+
+// WithInstrumentation makes the client report every API call to inst.
+func WithInstrumentation(inst Instrumentation) Option {
+	return func(c *Api) {
+		c.instrumentation = inst
+	}
+}
+
+// orderIdEndpoints lists the endpoints whose "id" field is an order id
+// rather than a receipt or refund id.
+var orderIdEndpoints = map[string]bool{
+	"v2/order/fetch":          true,
+	"v2/order/cancel":         true,
+	"v2/order/capture":        true,
+	"v2/order/refund":         true,
+	"v2/order/refund/partial": true,
+}
+
+// callOrderId returns the order id named by a request to endpoint.
+func callOrderId(endpoint string, requestBody map[string]interface{}) string {
+	if id, ok := requestBody["order_id"].(string); ok {
+		return id
+	}
+	if id, ok := requestBody["id"].(string); ok && orderIdEndpoints[endpoint] {
+		return id
+	}
+	return ""
+}
+
+// startCall reports the start of a call to the client's Instrumentation.
+// The returned func must be called with the outcome; it does nothing when
+// the client is not instrumented.
+func (c *Api) startCall(ctx context.Context, name string, endpoint string, requestBody map[string]interface{}) (context.Context, func(CallResult)) {
+	if c.instrumentation == nil {
+		return ctx, func(CallResult) {}
+	}
+	call := CallInfo{Method: name, Endpoint: endpoint, OrderId: callOrderId(endpoint, requestBody)}
+	start := time.Now()
+	ctx, end := c.instrumentation.StartCall(ctx, call)
+	return ctx, func(result CallResult) {
+		if result.OrderId == "" {
+			result.OrderId = call.OrderId
+		}
+		result.Duration = time.Since(start)
+		end(result)
+	}
+}
@@ -0,0 +1,54 @@
+package paytring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingInstrumentation keeps every call it is told about.
+type recordingInstrumentation struct {
+	calls   []CallInfo
+	results []CallResult
+}
+
+func (r *recordingInstrumentation) StartCall(ctx context.Context, call CallInfo) (context.Context, func(CallResult)) {
+	r.calls = append(r.calls, call)
+	return ctx, func(result CallResult) {
+		r.results = append(r.results, result)
+	}
+}
+
+func TestInstrumentationReportsCalls(t *testing.T) {
+	server := paytringtest.NewServer(apiKey, apiSecret)
+	defer server.Close()
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_FETCH", Amount: 1000})
+	server.FailNext("v2/order/fetch", paytringtest.Failure{Status: 503})
+
+	inst := &recordingInstrumentation{}
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithInstrumentation(inst), WithRetryPolicy(fastRetries))
+
+	_, err := client.FetchOrder("TEST_ORDER_ID_FETCH", "normal")
+	assert.NoError(t, err)
+	created, err := client.CreateOrder(NewMoney(1000, "INR"), "TEST_RECEIPT_INSTRUMENT", "https://example.com/callback", Customer{})
+	assert.NoError(t, err)
+	_, err = client.CancelOrder("MISSING")
+	assert.Error(t, err)
+
+	assert.Equal(t, []CallInfo{
+		{Method: "FetchOrder", Endpoint: "v2/order/fetch", OrderId: "TEST_ORDER_ID_FETCH"},
+		{Method: "CreateOrder", Endpoint: "v2/order/create"},
+		{Method: "CancelOrder", Endpoint: "v2/order/cancel", OrderId: "MISSING"},
+	}, inst.calls)
+
+	assert.Len(t, inst.results, 3)
+	assert.Equal(t, 2, inst.results[0].Attempts)
+	assert.Equal(t, 200, inst.results[0].StatusCode)
+	assert.NoError(t, inst.results[0].Err)
+	assert.Equal(t, created.OrderId, inst.results[1].OrderId)
+	assert.Equal(t, 404, inst.results[2].StatusCode)
+	assert.Error(t, inst.results[2].Err)
+	assert.Greater(t, int64(inst.results[0].Duration), int64(0))
+}
@@ -0,0 +1,210 @@
+package paytring
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// OrderStatus is the state of an order in its lifecycle:
+//
+//	created → pending → authorized → captured → partially refunded → refunded
+//
+// An order that is not captured can end up cancelled or failed instead.
+type OrderStatus string
+
+const (
+	OrderCreated    OrderStatus = "created"
+	OrderPending    OrderStatus = "pending"
+	OrderAuthorized OrderStatus = "authorized"
+	// OrderCaptured is reported by Paytring as "success".
+	OrderCaptured          OrderStatus = "success"
+	OrderPartiallyRefunded OrderStatus = "partially_refunded"
+	OrderRefunded          OrderStatus = "refunded"
+	OrderCancelled         OrderStatus = "cancelled"
+	OrderFailed            OrderStatus = "failed"
+)
+
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderCreated:           {OrderPending, OrderAuthorized, OrderCaptured, OrderCancelled, OrderFailed},
+	OrderPending:           {OrderAuthorized, OrderCaptured, OrderCancelled, OrderFailed},
+	OrderAuthorized:        {OrderCaptured, OrderCancelled, OrderFailed},
+	OrderCaptured:          {OrderPartiallyRefunded, OrderRefunded},
+	OrderPartiallyRefunded: {OrderPartiallyRefunded, OrderRefunded},
+}
+
+// Normalize maps the spellings Paytring uses for the same state onto the
+// constants above, e.g. "captured" and "SUCCESS" onto OrderCaptured.
+func (s OrderStatus) Normalize() OrderStatus {
+	switch status := OrderStatus(strings.ToLower(string(s))); status {
+	case "captured", "paid":
+		return OrderCaptured
+	case "partial_refunded", "partially-refunded":
+		return OrderPartiallyRefunded
+	case "canceled":
+		return OrderCancelled
+	default:
+		return status
+	}
+}
+
+// CanTransition reports whether an order may move from s to next.
+func (s OrderStatus) CanTransition(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s.Normalize()] {
+		if allowed == next.Normalize() {
+			return true
+		}
+	}
+	return false
+}
+
+// terminalStatuses are the statuses in which the payment is settled for
+// good. Captured orders may still be refunded.
+var terminalStatuses = map[OrderStatus]bool{
+	OrderCaptured:          true,
+	OrderPartiallyRefunded: true,
+	OrderRefunded:          true,
+	OrderCancelled:         true,
+	OrderFailed:            true,
+}
+
+// Terminal reports whether the payment of the order is settled for good.
+// Empty and unknown statuses are not terminal, so that WaitForOrder keeps
+// polling through them.
+func (s OrderStatus) Terminal() bool {
+	return terminalStatuses[s.Normalize()]
+}
+
+// CanCapture reports whether the order is authorized and waiting to be
+// captured.
+func (o *Order) CanCapture() bool {
+	return o.Status.Normalize() == OrderAuthorized
+}
+
+// CanCancel reports whether the order can still be cancelled.
+func (o *Order) CanCancel() bool {
+	return o.Status.CanTransition(OrderCancelled)
+}
+
+// CanRefund reports whether any part of the order can still be refunded.
+func (o *Order) CanRefund() bool {
+	return o.Status.CanTransition(OrderRefunded) && o.RefundableAmount() > 0
+}
+
+// RefundableAmount is what is left to refund on a captured order.
+func (o *Order) RefundableAmount() Amount {
+	if !o.Status.CanTransition(OrderRefunded) || o.RefundedAmount >= o.Amount {
+		return 0
+	}
+	return o.Amount - o.RefundedAmount
+}
+
+// TransitionError is returned in guarded mode when an order is known to be
+// in a state that does not allow the requested call.
+type TransitionError struct {
+	OrderId string
+	Status  OrderStatus
+	Action  string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot %s order %s in status %s", e.Action, e.OrderId, e.Status)
+}
+
+// WithOrderGuard makes the client remember the last state it saw for every
+// order and refuse CaptureOrder, CancelOrder, RefundOrder and PartialRefund
+// calls that a final state forbids, before any request is made. Any other
+// state may be stale, e.g. once the customer paid on the hosted checkout,
+// so calls on those orders are passed through, as are calls on orders the
+// client has not seen yet.
+func WithOrderGuard() Option {
+	return func(c *Api) {
+		c.guard = &orderGuard{orders: map[string]*Order{}}
+	}
+}
+
+// finalStatuses are the states an order never leaves, so the guard can
+// trust them however old they are.
+var finalStatuses = map[OrderStatus]bool{
+	OrderRefunded:  true,
+	OrderCancelled: true,
+	OrderFailed:    true,
+}
+
+// orderGuard holds the last known state of orders. A nil guard allows
+// everything and records nothing.
+type orderGuard struct {
+	mu     sync.Mutex
+	orders map[string]*Order
+}
+
+func (g *orderGuard) observe(order *Order) {
+	if g == nil || order == nil || order.OrderId == "" || order.Status == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	snapshot := *order
+	snapshot.Status = snapshot.Status.Normalize()
+	snapshot.Raw = nil
+	g.orders[order.OrderId] = &snapshot
+}
+
+func (g *orderGuard) observeCreated(created *CreatedOrder, amount Money) {
+	g.observe(&Order{OrderId: created.OrderId, Amount: Amount(amount.Amount), Currency: amount.Currency, Status: OrderCreated})
+}
+
+func (g *orderGuard) observeRefund(orderId string, refund *Refund) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	order, ok := g.orders[orderId]
+	if !ok {
+		return
+	}
+	order.RefundedAmount += refund.Amount
+	// Full refunds may come back without an amount.
+	if refund.Amount == 0 || order.RefundedAmount >= order.Amount {
+		order.Status = OrderRefunded
+		order.RefundedAmount = order.Amount
+	} else {
+		order.Status = OrderPartiallyRefunded
+	}
+}
+
+func (g *orderGuard) check(orderId string, action string, allowed func(*Order) bool) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	order, ok := g.orders[orderId]
+	if !ok || !finalStatuses[order.Status] || allowed(order) {
+		return nil
+	}
+	return &TransitionError{OrderId: orderId, Status: order.Status, Action: action}
+}
+
+func (g *orderGuard) checkRefund(orderId string, amount Money) error {
+	if err := g.check(orderId, "refund", (*Order).CanRefund); err != nil {
+		return err
+	}
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	order, ok := g.orders[orderId]
+	if !ok {
+		return nil
+	}
+	verr := &ValidationError{}
+	if order.Currency != "" && amount.Currency != "" && !strings.EqualFold(order.Currency, amount.Currency) {
+		verr.add("amount", "currency %s does not match order currency %s", amount.Currency, order.Currency)
+	} else if order.Status.CanTransition(OrderRefunded) && Amount(amount.Amount) > order.RefundableAmount() {
+		verr.add("amount", "exceeds the refundable amount of %d", order.RefundableAmount())
+	}
+	return verr.err()
+}
@@ -0,0 +1,107 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatusTransitions(t *testing.T) {
+	assert.True(t, OrderCreated.CanTransition(OrderPending))
+	assert.True(t, OrderAuthorized.CanTransition(OrderCaptured))
+	assert.True(t, OrderStatus("CAPTURED").CanTransition(OrderRefunded))
+	assert.False(t, OrderCaptured.CanTransition(OrderCancelled))
+	assert.False(t, OrderRefunded.CanTransition(OrderPartiallyRefunded))
+	assert.True(t, OrderCancelled.Terminal())
+	assert.False(t, OrderPending.Terminal())
+	assert.True(t, OrderStatus("CAPTURED").Terminal())
+	assert.False(t, OrderStatus("").Terminal())
+	assert.False(t, OrderStatus("on_hold").Terminal())
+}
+
+func TestOrderHelpers(t *testing.T) {
+	authorized := &Order{Status: OrderAuthorized, Amount: 1000}
+	assert.True(t, authorized.CanCapture())
+	assert.True(t, authorized.CanCancel())
+	assert.False(t, authorized.CanRefund())
+	assert.Equal(t, Amount(0), authorized.RefundableAmount())
+
+	partial := &Order{Status: OrderPartiallyRefunded, Amount: 1000, RefundedAmount: 300}
+	assert.False(t, partial.CanCapture())
+	assert.True(t, partial.CanRefund())
+	assert.Equal(t, Amount(700), partial.RefundableAmount())
+
+	refunded := &Order{Status: OrderRefunded, Amount: 1000, RefundedAmount: 1000}
+	assert.False(t, refunded.CanRefund())
+}
+
+func TestOrderGuardRefusesIllegalCalls(t *testing.T) {
+	server := paytringtest.NewServer(apiKey, apiSecret)
+	defer server.Close()
+	server.AddOrder(paytringtest.Order{OrderId: "772677896533967081", Amount: 1000, Status: paytringtest.StatusCreated})
+	server.AddOrder(paytringtest.Order{OrderId: "772666991739703525", Amount: 1000, Status: paytringtest.StatusCancelled})
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithOrderGuard())
+
+	_, err := client.FetchOrder("772666991739703525", "normal")
+	assert.NoError(t, err)
+
+	_, err = client.CaptureOrder("772666991739703525")
+	var transitionErr *TransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, OrderCancelled, transitionErr.Status)
+	assert.Equal(t, 0, server.Calls("v2/order/capture"))
+
+	// A created order may have been paid since, so it is not refused.
+	_, err = client.FetchOrder("772677896533967081", "normal")
+	assert.NoError(t, err)
+	server.SetOrderStatus("772677896533967081", paytringtest.StatusSuccess)
+
+	_, err = client.PartialRefund("772677896533967081", NewMoney(600, "INR"))
+	assert.NoError(t, err)
+
+	_, err = client.PartialRefund("772677896533967081", NewMoney(600, "INR"))
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "exceeds the refundable amount of 400", verr.FieldError("amount"))
+	assert.Equal(t, 1, server.Calls("v2/order/refund/partial"))
+
+	_, err = client.RefundOrder("772677896533967081")
+	assert.NoError(t, err)
+	_, err = client.RefundOrder("772677896533967081")
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, OrderRefunded, transitionErr.Status)
+	assert.Equal(t, 1, server.Calls("v2/order/refund"))
+
+	// Orders the client has never seen are not checked.
+	_, err = client.CancelOrder("unknown")
+	assert.Error(t, err)
+	assert.Equal(t, 1, server.Calls("v2/order/cancel"))
+}
+
+func TestOrderGuardPassesThroughCreatedOrders(t *testing.T) {
+	server := paytringtest.NewServer(apiKey, apiSecret)
+	defer server.Close()
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithOrderGuard())
+
+	created, err := client.CreateOrder(NewMoney(1000, "INR"), "TEST_GUARD_1", "https://example.com/callback", Customer{Name: "John Doe"})
+	assert.NoError(t, err)
+	server.SetOrderStatus(created.OrderId, paytringtest.StatusSuccess)
+
+	refund, err := client.RefundOrder(created.OrderId)
+	assert.NoError(t, err)
+	assert.Equal(t, "processed", refund.Status)
+}
+
+func TestOrderGuardMarksFullRefundsWithoutAmount(t *testing.T) {
+	g := &orderGuard{orders: map[string]*Order{}}
+	g.observe(&Order{OrderId: "772677896533967081", Amount: 1000, Status: OrderCaptured})
+	g.observeRefund("772677896533967081", &Refund{})
+
+	err := g.check("772677896533967081", "refund", (*Order).CanRefund)
+	var transitionErr *TransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, OrderRefunded, transitionErr.Status)
+}
@@ -0,0 +1,344 @@
+package paytring
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LinkStatus is the state of a payment link.
+type LinkStatus string
+
+const (
+	LinkCreated   LinkStatus = "created"
+	LinkPaid      LinkStatus = "paid"
+	LinkExpired   LinkStatus = "expired"
+	LinkCancelled LinkStatus = "cancelled"
+)
+
+// Terminal reports whether the link can no longer be paid.
+func (s LinkStatus) Terminal() bool {
+	switch s {
+	case LinkPaid, LinkExpired, LinkCancelled:
+		return true
+	}
+	return false
+}
+
+// LinkChannel is a way of sending a payment link to the customer.
+type LinkChannel string
+
+const (
+	LinkSMS   LinkChannel = "sms"
+	LinkEmail LinkChannel = "email"
+)
+
+// LinkRequest describes a payment link to create with CreateLink.
+type LinkRequest struct {
+	Amount      Money
+	ReceiptId   string
+	Description string
+	Customer    Customer
+	// CallbackUrl is where the customer lands after paying, optional.
+	CallbackUrl string
+	// ExpireAt is when the link stops accepting payments. Zero leaves it
+	// to Paytring's default.
+	ExpireAt time.Time
+	// Notify lists the channels the link is sent over once created.
+	Notify []LinkChannel
+	// Reminders are sent over the Notify channels this long after the
+	// link was created, if it is still unpaid by then.
+	Reminders []time.Duration
+	Notes     *Notes
+}
+
+// NewLink starts a LinkRequest for amount identified by receiptId.
+func NewLink(amount Money, receiptId string) *LinkRequest {
+	return &LinkRequest{Amount: amount, ReceiptId: receiptId}
+}
+
+// Validate checks the whole request without sending it. The returned error
+// is a *ValidationError listing every problem found.
+func (r *LinkRequest) Validate() error {
+	return r.validate(time.Now())
+}
+
+func (r *LinkRequest) validate(now time.Time) error {
+	verr := &ValidationError{}
+
+	if !r.Amount.IsPositive() {
+		verr.add("amount", "must be greater than zero")
+	}
+	if _, err := CurrencyExponent(r.currency()); err != nil {
+		verr.add("currency", "must be a three letter ISO 4217 code")
+	}
+	if r.ReceiptId == "" {
+		verr.add("receipt_id", "is required")
+	}
+	if r.CallbackUrl != "" && !validCallbackURL(r.CallbackUrl) {
+		verr.add("callback_url", "must be an absolute http or https URL")
+	}
+	if !r.ExpireAt.IsZero() && !r.ExpireAt.After(now) {
+		verr.add("expire_at", "must be in the future")
+	}
+
+	for i, channel := range r.Notify {
+		switch channel {
+		case LinkSMS:
+			if r.Customer.Phone == "" {
+				verr.add("customer.phone", "is required to send the link by sms")
+			}
+		case LinkEmail:
+			if r.Customer.Email == "" {
+				verr.add("customer.email", "is required to send the link by email")
+			}
+		default:
+			verr.add(fmt.Sprintf("notify.%d", i), "must be %s or %s", LinkSMS, LinkEmail)
+		}
+	}
+
+	if len(r.Reminders) > 0 && len(r.Notify) == 0 {
+		verr.add("reminders", "need at least one notify channel")
+	}
+	for i, after := range r.Reminders {
+		if after <= 0 {
+			verr.add(fmt.Sprintf("reminders.%d", i), "must be positive")
+		} else if !r.ExpireAt.IsZero() && !now.Add(after).Before(r.ExpireAt) {
+			verr.add(fmt.Sprintf("reminders.%d", i), "would be sent after the link expires")
+		}
+	}
+
+	return verr.err()
+}
+
+func (r *LinkRequest) currency() string {
+	return currencyOrDefault(r.Amount.Currency)
+}
+
+// body builds the create link payload for key.
+func (r *LinkRequest) body(key string) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"key":        key,
+		"receipt_id": r.ReceiptId,
+		"amount":     r.Amount.Minor(),
+		"currency":   r.currency(),
+		"cname":      r.Customer.Name,
+		"phone":      r.Customer.Phone,
+		"email":      r.Customer.Email,
+		"hash":       "none",
+	}
+
+	addToMapIfNotBlank(requestBody, "description", r.Description)
+	addToMapIfNotBlank(requestBody, "callback_url", r.CallbackUrl)
+	if !r.ExpireAt.IsZero() {
+		requestBody["expire_at"] = r.ExpireAt.UTC().Format(time.RFC3339)
+	}
+
+	if len(r.Notify) > 0 {
+		channels := make([]string, 0, len(r.Notify))
+		for _, channel := range r.Notify {
+			channels = append(channels, string(channel))
+		}
+		requestBody["notify"] = channels
+	}
+
+	if len(r.Reminders) > 0 {
+		reminders := make([]int64, 0, len(r.Reminders))
+		for _, after := range r.Reminders {
+			reminders = append(reminders, int64(after/time.Second))
+		}
+		requestBody["reminders"] = reminders
+	}
+
+	if notes := notesMap(r.Notes); len(notes) > 0 {
+		requestBody["notes"] = notes
+	}
+
+	return requestBody
+}
+
+// LinkPayment is an order paid through a payment link.
+type LinkPayment struct {
+	OrderId string      `json:"order_id"`
+	Amount  Amount      `json:"amount"`
+	Status  OrderStatus `json:"order_status"`
+}
+
+// PaymentLink is a payment link as returned by CreateLink and friends.
+// Payments lists the orders made through the link; fetch one with
+// FetchOrder for its full details.
+type PaymentLink struct {
+	LinkId      string        `json:"link_id"`
+	ReceiptId   string        `json:"receipt_id"`
+	Url         string        `json:"url"`
+	Amount      Amount        `json:"amount"`
+	AmountPaid  Amount        `json:"amount_paid"`
+	Currency    string        `json:"currency"`
+	Status      LinkStatus    `json:"link_status"`
+	Description string        `json:"description"`
+	Customer    Customer      `json:"customer"`
+	Notes       Notes         `json:"notes"`
+	ExpireAt    string        `json:"expire_at"`
+	CreatedAt   string        `json:"created_at"`
+	Payments    []LinkPayment `json:"payments"`
+	Response
+}
+
+// PaidOrderId returns the order that settled the link, if it is paid.
+func (l *PaymentLink) PaidOrderId() string {
+	for _, payment := range l.Payments {
+		if payment.Status.Normalize() == OrderCaptured {
+			return payment.OrderId
+		}
+	}
+	return ""
+}
+
+// PaymentLinks is returned by ListLinks.
+type PaymentLinks struct {
+	Links []PaymentLink `json:"links"`
+	Response
+}
+
+// LinkFilter narrows down ListLinks. Zero fields are ignored.
+type LinkFilter struct {
+	Status    LinkStatus
+	ReceiptId string
+	From      time.Time
+	To        time.Time
+	// Page starts at 1. Count is the number of links per page.
+	Page  int
+	Count int
+}
+
+func (c *Api) CreateLink(req *LinkRequest) (*PaymentLink, error) {
+	return c.CreateLinkCtx(context.Background(), req)
+}
+
+// CreateLinkCtx validates req and creates the payment link. Paytring sends
+// it over req.Notify right away.
+func (c *Api) CreateLinkCtx(ctx context.Context, req *LinkRequest) (*PaymentLink, error) {
+
+	if err := req.validate(c.now()); err != nil {
+		return nil, err
+	}
+
+	return c.linkCall(ctx, "CreateLink", "v2/link/create", req.body(c.ApiKey))
+}
+
+func (c *Api) FetchLink(linkId string) (*PaymentLink, error) {
+	return c.FetchLinkCtx(context.Background(), linkId)
+}
+
+func (c *Api) FetchLinkCtx(ctx context.Context, linkId string) (*PaymentLink, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   linkId,
+		"hash": "none",
+	}
+
+	return c.linkCall(ctx, "FetchLink", "v2/link/fetch", requestBody)
+}
+
+func (c *Api) ListLinks(filter LinkFilter) (*PaymentLinks, error) {
+	return c.ListLinksCtx(context.Background(), filter)
+}
+
+func (c *Api) ListLinksCtx(ctx context.Context, filter LinkFilter) (*PaymentLinks, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"hash": "none",
+	}
+	addToMapIfNotBlank(requestBody, "link_status", string(filter.Status))
+	addToMapIfNotBlank(requestBody, "receipt_id", filter.ReceiptId)
+	if !filter.From.IsZero() {
+		requestBody["from"] = filter.From.UTC().Format(time.RFC3339)
+	}
+	if !filter.To.IsZero() {
+		requestBody["to"] = filter.To.UTC().Format(time.RFC3339)
+	}
+	if filter.Page > 0 {
+		requestBody["page"] = filter.Page
+	}
+	if filter.Count > 0 {
+		requestBody["count"] = filter.Count
+	}
+
+	body, response, err := c.post(ctx, "ListLinks", "v2/link/list", c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var links PaymentLinks
+	if err := decodeResponse(body, "", response, &links); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for ListLinks: %w", err)
+	}
+
+	return &links, nil
+}
+
+func (c *Api) CancelLink(linkId string) (*PaymentLink, error) {
+	return c.CancelLinkCtx(context.Background(), linkId)
+}
+
+// CancelLinkCtx stops the link from accepting payments. Paid, expired and
+// cancelled links cannot be cancelled.
+func (c *Api) CancelLinkCtx(ctx context.Context, linkId string) (*PaymentLink, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   linkId,
+		"hash": "none",
+	}
+
+	return c.linkCall(ctx, "CancelLink", "v2/link/cancel", requestBody)
+}
+
+func (c *Api) ResendLink(linkId string, channels ...LinkChannel) (*PaymentLink, error) {
+	return c.ResendLinkCtx(context.Background(), linkId, channels...)
+}
+
+// ResendLinkCtx sends the link to the customer again, over channels or,
+// when none are given, over the channels it was created with.
+func (c *Api) ResendLinkCtx(ctx context.Context, linkId string, channels ...LinkChannel) (*PaymentLink, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   linkId,
+		"hash": "none",
+	}
+
+	if len(channels) > 0 {
+		notify := make([]string, 0, len(channels))
+		for _, channel := range channels {
+			if channel != LinkSMS && channel != LinkEmail {
+				verr := &ValidationError{}
+				verr.add("notify", "must be %s or %s", LinkSMS, LinkEmail)
+				return nil, verr
+			}
+			notify = append(notify, string(channel))
+		}
+		requestBody["notify"] = notify
+	}
+
+	return c.linkCall(ctx, "ResendLink", "v2/link/resend", requestBody)
+}
+
+// linkCall posts requestBody to endpoint and decodes the link Paytring
+// returns.
+func (c *Api) linkCall(ctx context.Context, name string, endpoint string, requestBody map[string]interface{}) (*PaymentLink, error) {
+
+	body, response, err := c.post(ctx, name, endpoint, c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var link PaymentLink
+	if err := decodeResponse(body, "link", response, &link); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for %s: %w", name, err)
+	}
+
+	return &link, nil
+}
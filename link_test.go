@@ -0,0 +1,85 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkRequestValidate(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	req := NewLink(NewMoney(0, "INR"), "")
+	req.ExpireAt = now.Add(-time.Hour)
+	req.Notify = []LinkChannel{LinkSMS, "whatsapp"}
+	req.Reminders = []time.Duration{0}
+
+	var verr *ValidationError
+	assert.True(t, errors.As(req.validate(now), &verr))
+	assert.Equal(t, "must be greater than zero", verr.FieldError("amount"))
+	assert.Equal(t, "is required", verr.FieldError("receipt_id"))
+	assert.Equal(t, "must be in the future", verr.FieldError("expire_at"))
+	assert.Equal(t, "is required to send the link by sms", verr.FieldError("customer.phone"))
+	assert.Equal(t, "must be sms or email", verr.FieldError("notify.1"))
+	assert.Equal(t, "must be positive", verr.FieldError("reminders.0"))
+
+	req = NewLink(NewMoney(50000, "INR"), "INV-1")
+	req.Customer = Customer{Email: "john.doe@example.com"}
+	req.ExpireAt = now.Add(48 * time.Hour)
+	req.Notify = []LinkChannel{LinkEmail}
+	req.Reminders = []time.Duration{24 * time.Hour, 72 * time.Hour}
+	assert.True(t, errors.As(req.validate(now), &verr))
+	assert.Equal(t, "would be sent after the link expires", verr.FieldError("reminders.1"))
+	assert.Len(t, verr.Fields, 1)
+}
+
+func TestPaymentLinkLifecycle(t *testing.T) {
+	client, server := newTestClient(t)
+
+	req := NewLink(NewMoney(50000, "INR"), "INV-1")
+	req.Description = "Invoice INV-1"
+	req.Customer = Customer{Name: "John Doe", Phone: "9876543210"}
+	req.ExpireAt = time.Now().Add(24 * time.Hour)
+	req.Notify = []LinkChannel{LinkSMS}
+	req.Reminders = []time.Duration{time.Hour}
+	req.Notes = &Notes{Udf1: "collections"}
+
+	link, err := client.CreateLink(req)
+	assert.NoError(t, err)
+	assert.Equal(t, LinkCreated, link.Status)
+	assert.Equal(t, Amount(50000), link.Amount)
+	assert.Equal(t, "collections", link.Notes.Udf1)
+	assert.NotEmpty(t, link.Url)
+
+	_, err = client.ResendLink(link.LinkId, LinkEmail, LinkSMS)
+	assert.NoError(t, err)
+	stored, _ := server.Link(link.LinkId)
+	assert.Equal(t, 2, stored.Sent)
+
+	order, ok := server.PayLink(link.LinkId)
+	assert.True(t, ok)
+
+	link, err = client.FetchLink(link.LinkId)
+	assert.NoError(t, err)
+	assert.Equal(t, LinkPaid, link.Status)
+	assert.True(t, link.Status.Terminal())
+	assert.Equal(t, Amount(50000), link.AmountPaid)
+	assert.Equal(t, order.OrderId, link.PaidOrderId())
+
+	_, err = client.CancelLink(link.LinkId)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+
+	other := server.AddLink(paytringtest.Link{ReceiptId: "INV-2", Amount: 1000})
+	cancelled, err := client.CancelLink(other.LinkId)
+	assert.NoError(t, err)
+	assert.Equal(t, LinkCancelled, cancelled.Status)
+
+	links, err := client.ListLinks(LinkFilter{Status: LinkPaid})
+	assert.NoError(t, err)
+	assert.Len(t, links.Links, 1)
+	assert.Equal(t, "INV-1", links.Links[0].ReceiptId)
+}
@@ -0,0 +1,65 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Logger receives a debug record for every request the client sends and
+// every response it gets back. *slog.Logger satisfies it, so does any
+// adapter around another logging library. args are alternating keys and
+// values, as with slog.
+//
+// Everything passed to a Logger has gone through Redact first.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// WithLogger makes the client log requests and responses to logger at
+// debug level. Without it the client logs nothing.
+func WithLogger(logger Logger) Option {
+	return func(c *Api) {
+		c.logger = logger
+	}
+}
+
+// logRequest records an attempt of name about to be sent to endpoint.
+func (c *Api) logRequest(ctx context.Context, name string, endpoint string, attempt int, headers map[string]string, requestBody map[string]interface{}) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.DebugContext(ctx, "paytring request",
+		"method", name,
+		"endpoint", endpoint,
+		"attempt", attempt,
+		"headers", redactHeaders(headers),
+		"body", redactMap(requestBody),
+	)
+}
+
+// logResponse records the outcome of an attempt. status is 0 when no
+// response was received.
+func (c *Api) logResponse(ctx context.Context, name string, endpoint string, attempt int, status int, requestID string, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+	args := []interface{}{
+		"method", name,
+		"endpoint", endpoint,
+		"attempt", attempt,
+		"status", status,
+		"latency", latency,
+	}
+	if requestID != "" {
+		args = append(args, "request_id", requestID)
+	}
+	if err != nil {
+		args = append(args, "error", Redact(err.Error()))
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code != "" {
+			args = append(args, "error_code", apiErr.Code)
+		}
+	}
+	c.logger.DebugContext(ctx, "paytring response", args...)
+}
@@ -0,0 +1,80 @@
+package paytring
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger keeps every record it receives.
+type recordingLogger struct {
+	records []map[string]interface{}
+}
+
+func (l *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	record := map[string]interface{}{"msg": msg}
+	for i := 0; i+1 < len(args); i += 2 {
+		record[args[i].(string)] = args[i+1]
+	}
+	l.records = append(l.records, record)
+}
+
+func TestLoggerRecordsRequestsRedacted(t *testing.T) {
+	server := paytringtest.NewServer(apiKey, apiSecret)
+	defer server.Close()
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_PROCESS", Amount: 1000})
+
+	logger := &recordingLogger{}
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := client.ProcessOrder("TEST_ORDER_ID_PROCESS", "card", "", PaymentData{CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2099", Cvv: "123"}, "")
+	assert.Error(t, err)
+	_, err = client.FetchOrder("TEST_ORDER_ID_PROCESS", "normal")
+	assert.NoError(t, err)
+	_, err = client.FetchOrder("MISSING", "normal")
+	assert.Error(t, err)
+
+	assert.Len(t, logger.records, 4)
+	for _, record := range logger.records {
+		s := fmt.Sprint(record)
+		assert.NotContains(t, s, apiSecret)
+		assert.False(t, strings.Contains(s, "Basic ") && !strings.Contains(s, "[REDACTED]"), s)
+	}
+
+	request, response := logger.records[2], logger.records[3]
+	assert.Equal(t, "paytring request", request["msg"])
+	assert.Equal(t, "FetchOrder", request["method"])
+	assert.Equal(t, "v2/order/fetch", request["endpoint"])
+	assert.Equal(t, "[REDACTED]", request["headers"].(map[string]string)["Authorization"])
+	assert.Equal(t, "paytring response", response["msg"])
+	assert.Equal(t, 404, response["status"])
+	assert.NotNil(t, response["latency"])
+	assert.NotEmpty(t, response["error"])
+}
+
+func TestLoggerSilentByDefault(t *testing.T) {
+	client, _ := newTestClient(t)
+	assert.Nil(t, client.logger)
+}
+
+func TestLoggerAcceptsSlog(t *testing.T) {
+	server := paytringtest.NewServer(apiKey, apiSecret)
+	defer server.Close()
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_SLOG", Amount: 1000})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithLogger(logger))
+
+	_, err := client.FetchOrder("TEST_ORDER_ID_SLOG", "normal")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `msg="paytring request" method=FetchOrder endpoint=v2/order/fetch`)
+	assert.Contains(t, buf.String(), `msg="paytring response"`)
+	assert.NotContains(t, buf.String(), apiSecret)
+}
@@ -0,0 +1,90 @@
+package paytring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Call is a single attempt of an API request as seen by middleware.
+// Middleware may change Headers and Body before passing the call on.
+type Call struct {
+	// Method is the SDK method, e.g. "CreateOrder".
+	Method string
+	// Endpoint is the Paytring endpoint, e.g. "v2/order/create".
+	Endpoint string
+	// Attempt counts the attempts of the request, starting at 1.
+	Attempt int
+	// Headers are sent with the request. They are a copy, changing them
+	// only affects this attempt.
+	Headers map[string]string
+	// Body is sent as JSON. It is shared between attempts. Bodies signed
+	// with MakeHash are signed again after the middleware ran, so changes
+	// stay covered by the hash.
+	Body map[string]interface{}
+
+	// signed is set when Body carried a hash made by MakeHash. Other
+	// bodies keep the placeholder hash the endpoint expects, e.g. "none".
+	signed bool
+}
+
+// Reply is what Paytring answered to a Call.
+type Reply struct {
+	// StatusCode is the HTTP status, or 0 when no response was received.
+	StatusCode int
+	// Body is the raw response body. It is only set on success, failures
+	// carry it in APIError.Body.
+	Body []byte
+	// Response is the decoded body once HandleResponse accepted it.
+	Response map[string]interface{}
+}
+
+// RoundTrip sends call and returns Paytring's reply. The reply may be
+// non-nil along with an error, e.g. to carry the status of a rejected
+// request.
+type RoundTrip func(ctx context.Context, call *Call) (*Reply, error)
+
+// Middleware wraps a RoundTrip, e.g. to add headers, record metrics or
+// inject faults. It may call next any number of times, or not at all.
+type Middleware func(next RoundTrip) RoundTrip
+
+// Use adds middleware around every attempt of every request. The first
+// middleware added is the outermost, it sees the call first and the reply
+// last. Use is meant to be called while setting up the client, not
+// concurrently with requests.
+//
+//	client.Use(func(next paytring.RoundTrip) paytring.RoundTrip {
+//		return func(ctx context.Context, call *paytring.Call) (*paytring.Reply, error) {
+//			call.Headers["X-Tenant"] = tenantFrom(ctx)
+//			return next(ctx, call)
+//		}
+//	})
+func (c *Api) Use(middleware ...Middleware) *Api {
+	c.middleware = append(c.middleware, middleware...)
+	return c
+}
+
+// roundTrip returns the chain of c's middleware around the actual send.
+func (c *Api) roundTrip() RoundTrip {
+	rt := c.transmit
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	return rt
+}
+
+// transmit is the innermost RoundTrip: it encodes call and sends it.
+func (c *Api) transmit(ctx context.Context, call *Call) (*Reply, error) {
+	if call.signed {
+		call.Body["hash"] = c.ComputeHash(call.Body)
+	}
+
+	body, err := json.Marshal(call.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body for %s: %w", call.Method, err)
+	}
+
+	c.logRequest(ctx, call.Method, call.Endpoint, call.Attempt, call.Headers, call.Body)
+	status, respBody, response, err := c.send(ctx, call.Method, call.Endpoint, call.Attempt, call.Headers, body)
+	return &Reply{StatusCode: status, Body: respBody, Response: response}, err
+}
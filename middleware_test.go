@@ -0,0 +1,144 @@
+package paytring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"status":true,"order":{"order_id":"` + body["id"].(string) + `"}}`))
+	}))
+	defer server.Close()
+
+	var trace []string
+	record := func(name string) Middleware {
+		return func(next RoundTrip) RoundTrip {
+			return func(ctx context.Context, call *Call) (*Reply, error) {
+				trace = append(trace, name+" "+call.Method+" "+call.Endpoint)
+				reply, err := next(ctx, call)
+				trace = append(trace, name+" "+reply.Response["order"].(map[string]interface{})["order_id"].(string))
+				return reply, err
+			}
+		}
+	}
+	tenant := func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, call *Call) (*Reply, error) {
+			call.Headers["X-Tenant"] = "acme"
+			call.Body["id"] = "REWRITTEN"
+			return next(ctx, call)
+		}
+	}
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+	client.Use(record("outer"), record("inner")).Use(tenant)
+
+	order, err := client.FetchOrder("TEST_ORDER_ID_FETCH", "normal")
+	assert.NoError(t, err)
+	assert.Equal(t, "REWRITTEN", order.OrderId)
+	assert.Equal(t, "acme", headers[0].Get("X-Tenant"))
+	assert.Equal(t, []string{
+		"outer FetchOrder v2/order/fetch",
+		"inner FetchOrder v2/order/fetch",
+		"inner REWRITTEN",
+		"outer REWRITTEN",
+	}, trace)
+}
+
+func TestMiddlewareFaultInjectionIsRetried(t *testing.T) {
+	client, server := newTestClient(t)
+	client.RetryPolicy = fastRetries
+
+	var attempts []int
+	client.Use(func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, call *Call) (*Reply, error) {
+			attempts = append(attempts, call.Attempt)
+			if call.Attempt == 1 {
+				return &Reply{StatusCode: http.StatusServiceUnavailable}, &APIError{StatusCode: http.StatusServiceUnavailable, Message: "injected"}
+			}
+			return next(ctx, call)
+		}
+	})
+
+	_, err := client.FetchRefund("TEST_REFUND_ID_FETCH")
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, []int{1, 2}, attempts)
+	assert.Equal(t, 1, server.Calls("v2/order/refund/fetch"))
+}
+
+func TestMiddlewareBodyChangesAreSigned(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.Use(func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, call *Call) (*Reply, error) {
+			call.Body["vpa"] = "rewritten@okbank"
+			return next(ctx, call)
+		}
+	})
+
+	info, err := client.ValidateVPA("johndoe@okbank")
+	assert.NoError(t, err)
+	assert.Equal(t, "rewritten@okbank", info.Vpa)
+}
+
+func TestUnsignedBodiesKeepTheirHash(t *testing.T) {
+	var hashes []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		hashes = append(hashes, body["hash"])
+		w.Write([]byte(`{"status":true,"refund":{"refund_id":"R1"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+	client.Use(func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, call *Call) (*Reply, error) {
+			call.Headers["X-Tenant"] = "acme"
+			return next(ctx, call)
+		}
+	})
+
+	_, err := client.RefundOrder("771606428862383868")
+	assert.NoError(t, err)
+	_, err = client.FetchRefundStatus("R1")
+	assert.NoError(t, err)
+	_, err = client.FetchRefundAttempts("771606428862383868")
+	assert.NoError(t, err)
+	_, err = client.FetchRefund("R1")
+	assert.NoError(t, err)
+	_, err = client.FetchOrder("771606428862383868", "normal")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"null", "null", "null", "null", "none"}, hashes)
+}
+
+func TestCustomHeadersStayOnTheClient(t *testing.T) {
+	var got []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+		w.Write([]byte(`{"status":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+	client.SetCustomHeaders(map[string]string{"X-Team": "payments", "authorization": "Bearer other"})
+
+	_, err := client.FetchOrder("TEST_ORDER_ID_FETCH", "normal")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "payments", got[0].Get("X-Team"))
+	assert.Equal(t, client.MakeAuthHeader()["Authorization"], got[0].Get("Authorization"))
+	assert.Empty(t, client.http.Header)
+}
@@ -0,0 +1,169 @@
+package paytring
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Response carries the decoded JSON body returned by Paytring. It is
+// embedded in every typed response so that fields the SDK does not model
+// yet are still reachable through Raw.
+type Response struct {
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (r *Response) setRaw(raw map[string]interface{}) {
+	r.Raw = raw
+}
+
+type rawSetter interface {
+	setRaw(raw map[string]interface{})
+}
+
+// Amount is a value in the minor unit of its currency. Paytring sends
+// amounts both as JSON numbers and as numeric strings, Amount accepts
+// either form. Fractions of a minor unit are rejected rather than rounded.
+type Amount int64
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*a = 0
+		return nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*a = Amount(v)
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s", data)
+	}
+	if v != math.Trunc(v) {
+		return fmt.Errorf("invalid amount %s: fractions of a minor unit are not allowed", data)
+	}
+	*a = Amount(v)
+	return nil
+}
+
+// CreatedOrder is returned by CreateOrder. Url is the hosted checkout page
+// the customer should be sent to. Pg is only known here when the order was
+// pinned to a gateway or Paytring resolved the pool right away.
+type CreatedOrder struct {
+	OrderId  string `json:"order_id"`
+	Url      string `json:"url"`
+	Pg       string `json:"pg"`
+	PgPoolId string `json:"pg_pool_id"`
+	Response
+}
+
+// Order is an order as returned by FetchOrder and friends. Pg is the
+// gateway that handled the payment, which for orders routed through a pool
+// is the one Paytring picked from PgPoolId.
+type Order struct {
+	OrderId         string          `json:"order_id"`
+	ReceiptId       string          `json:"receipt_id"`
+	PgTransactionId string          `json:"pg_transaction_id"`
+	Amount          Amount          `json:"amount"`
+	RefundedAmount  Amount          `json:"refunded_amount"`
+	Currency        string          `json:"currency"`
+	Status          OrderStatus     `json:"order_status"`
+	Pg              string          `json:"pg"`
+	PgPoolId        string          `json:"pg_pool_id"`
+	Method          string          `json:"method"`
+	Code            string          `json:"code"`
+	Customer        Customer        `json:"customer"`
+	Notes           Notes           `json:"notes"`
+	BillingAddress  BillingAddress  `json:"billing_address"`
+	ShippingAddress ShippingAddress `json:"shipping_address"`
+	Response
+}
+
+// ProcessedOrder is returned by ProcessOrder.
+type ProcessedOrder struct {
+	OrderId string `json:"order_id"`
+	Method  string `json:"method"`
+	Pg      string `json:"pg"`
+	Url     string `json:"url"`
+	// IntentUrl and QrString are set for UPI intent and QR payments.
+	IntentUrl string `json:"intent_url"`
+	QrString  string `json:"qr_string"`
+	Response
+}
+
+// Refund is a single refund as returned by RefundOrder, PartialRefund,
+// FetchRefund and FetchRefundStatus.
+type Refund struct {
+	RefundId  string `json:"refund_id"`
+	OrderId   string `json:"order_id"`
+	Amount    Amount `json:"amount"`
+	Currency  string `json:"currency"`
+	Status    string `json:"refund_status"`
+	CreatedAt string `json:"created_at"`
+	Response
+}
+
+// RefundAttempt is one entry of the refund history of an order.
+type RefundAttempt struct {
+	RefundId  string `json:"refund_id"`
+	Amount    Amount `json:"amount"`
+	Status    string `json:"refund_status"`
+	Message   string `json:"message"`
+	CreatedAt string `json:"created_at"`
+}
+
+// RefundAttempts is returned by FetchRefundAttempts.
+type RefundAttempts struct {
+	Attempts []RefundAttempt `json:"refunds"`
+	Response
+}
+
+// VPAInfo is returned by ValidateVPA.
+type VPAInfo struct {
+	Vpa   string `json:"vpa"`
+	Valid bool   `json:"valid"`
+	Name  string `json:"name"`
+	Response
+}
+
+// BinInfo is returned by ValidateCard.
+type BinInfo struct {
+	Bin     string `json:"bin"`
+	Network string `json:"network"`
+	Type    string `json:"type"`
+	Issuer  string `json:"issuer"`
+	Country string `json:"country"`
+	Response
+}
+
+// ExchangeRate is returned by CurrencyConversion.
+type ExchangeRate struct {
+	From string      `json:"from"`
+	To   string      `json:"to"`
+	Rate json.Number `json:"rate"`
+	Response
+}
+
+// decodeResponse decodes body into out. When key names a JSON object in the
+// body, that object is decoded instead of the top level. raw is attached to
+// out so callers can reach fields that are not modelled.
+func decodeResponse(body []byte, key string, raw map[string]interface{}, out rawSetter) error {
+	data := body
+	if key != "" {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return err
+		}
+		if nested, ok := envelope[key]; ok && strings.HasPrefix(strings.TrimSpace(string(nested)), "{") {
+			data = nested
+		}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return err
+	}
+	out.setRaw(raw)
+	return nil
+}
@@ -0,0 +1,41 @@
+package paytring
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmountUnmarshal(t *testing.T) {
+	var amounts []Amount
+	err := json.Unmarshal([]byte(`[100, "250", "100.0", null]`), &amounts)
+	assert.NoError(t, err)
+	assert.Equal(t, []Amount{100, 250, 100, 0}, amounts)
+
+	var amount Amount
+	assert.EqualError(t, json.Unmarshal([]byte(`"99.6"`), &amount), `invalid amount "99.6": fractions of a minor unit are not allowed`)
+	assert.Error(t, json.Unmarshal([]byte(`99.6`), &amount))
+	assert.Equal(t, Amount(0), amount)
+}
+
+func TestDecodeResponse(t *testing.T) {
+	body := []byte(`{"status":true,"order":{"order_id":"771606428862383868","amount":"1000","order_status":"success","customer":{"name":"John Doe"}},"extra":"kept"}`)
+
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &raw))
+
+	var order Order
+	assert.NoError(t, decodeResponse(body, "order", raw, &order))
+	assert.Equal(t, "771606428862383868", order.OrderId)
+	assert.Equal(t, Amount(1000), order.Amount)
+	assert.Equal(t, OrderCaptured, order.Status)
+	assert.Equal(t, "John Doe", order.Customer.Name)
+	assert.Equal(t, "kept", order.Raw["extra"])
+
+	created := []byte(`{"status":true,"order_id":"123","url":"https://example.com/pay/123"}`)
+	var createdOrder CreatedOrder
+	assert.NoError(t, decodeResponse(created, "", nil, &createdOrder))
+	assert.Equal(t, "123", createdOrder.OrderId)
+	assert.Equal(t, "https://example.com/pay/123", createdOrder.Url)
+}
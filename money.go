@@ -0,0 +1,191 @@
+package paytring
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor unit of Currency: paise for INR, cents
+// for USD, yen for JPY and fils for KWD. Every method that takes an amount
+// takes a Money, so a value in the wrong unit has to be converted
+// explicitly with ParseMoney or NewMoney.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not
+// a hundredth of the major unit. Any other three letter code has two
+// decimals.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimals of currency's minor unit.
+// It fails for anything that is not a three letter code.
+func CurrencyExponent(currency string) (int, error) {
+	currency = strings.ToUpper(currency)
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return 0, fmt.Errorf("invalid currency code %q", currency)
+	}
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent, nil
+	}
+	return 2, nil
+}
+
+// currencyOrDefault returns the first of codes that is set, upper cased,
+// and INR when none is.
+func currencyOrDefault(codes ...string) string {
+	for _, code := range codes {
+		if code != "" {
+			return strings.ToUpper(code)
+		}
+	}
+	return "INR"
+}
+
+// NewMoney returns minor units of currency, e.g. NewMoney(1050, "INR") for
+// ₹10.50.
+func NewMoney(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney parses a decimal string in major units, e.g. "10.50", into
+// Money. More decimals than the currency has are rejected rather than
+// rounded.
+func ParseMoney(decimal string, currency string) (Money, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := strings.TrimSpace(decimal)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", decimal)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimals for %s", decimal, exponent, strings.ToUpper(currency))
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	digits := strings.TrimLeft(whole+fraction, "0")
+	if digits == "" {
+		digits = "0"
+	}
+	if strings.Trim(whole+fraction, "0123456789") != "" {
+		return Money{}, fmt.Errorf("invalid amount %q", decimal)
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q is out of range", decimal)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return NewMoney(minor, currency), nil
+}
+
+// Decimal formats m in major units, e.g. "10.50" for 1050 paise.
+func (m Money) Decimal() string {
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil {
+		exponent = 2
+	}
+
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Minor returns the amount in minor units as Paytring expects it.
+func (m Money) Minor() string {
+	return strconv.FormatInt(m.Amount, 10)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Add returns m+o. It fails if the currencies differ or the sum overflows.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, fmt.Errorf("adding %s to %s overflows", o, m)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o. It fails if the currencies differ or the result
+// overflows.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("subtracting %s from %s overflows", o, m)
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Cmp compares m and o, returning -1, 0 or +1. It fails if the currencies
+// differ.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if !strings.EqualFold(m.Currency, o.Currency) {
+		return fmt.Errorf("currency mismatch: %s and %s", m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// Money returns the order amount with its currency.
+func (o *Order) Money() Money {
+	return NewMoney(int64(o.Amount), o.Currency)
+}
+
+// Money returns the refund amount with its currency.
+func (r *Refund) Money() Money {
+	return NewMoney(int64(r.Amount), r.Currency)
+}
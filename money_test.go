@@ -0,0 +1,81 @@
+package paytring
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		decimal  string
+		currency string
+		minor    int64
+		text     string
+	}{
+		{"10.50", "INR", 1050, "10.50 INR"},
+		{"10.5", "inr", 1050, "10.50 INR"},
+		{"0.05", "USD", 5, "0.05 USD"},
+		{"1500", "JPY", 1500, "1500 JPY"},
+		{"1.234", "KWD", 1234, "1.234 KWD"},
+		{"-2.5", "INR", -250, "-2.50 INR"},
+	}
+
+	for _, c := range cases {
+		m, err := ParseMoney(c.decimal, c.currency)
+		assert.NoError(t, err, c.decimal)
+		assert.Equal(t, c.minor, m.Amount, c.decimal)
+		assert.Equal(t, c.text, m.String(), c.decimal)
+	}
+}
+
+func TestParseMoneyRejects(t *testing.T) {
+	for _, c := range [][2]string{
+		{"10.505", "INR"},
+		{"1.5", "JPY"},
+		{"1,50", "INR"},
+		{"", "INR"},
+		{"10", "RUPEES"},
+		{"99999999999999999999", "INR"},
+	} {
+		_, err := ParseMoney(c[0], c[1])
+		assert.Error(t, err, c[0])
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := NewMoney(1000, "INR").Add(NewMoney(250, "INR"))
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(1250, "INR"), sum)
+
+	diff, err := sum.Sub(NewMoney(1500, "INR"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-2.50 INR", diff.String())
+
+	cmp, err := NewMoney(1, "INR").Cmp(NewMoney(2, "INR"))
+	assert.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+
+	_, err = NewMoney(1000, "INR").Add(NewMoney(1000, "USD"))
+	assert.Error(t, err)
+
+	_, err = NewMoney(math.MaxInt64, "INR").Add(NewMoney(1, "INR"))
+	assert.Error(t, err)
+
+	_, err = NewMoney(0, "INR").Sub(NewMoney(math.MinInt64, "INR"))
+	assert.Error(t, err)
+}
+
+func TestOrderCurrencyMismatch(t *testing.T) {
+	req := NewOrder(NewMoney(1000, "INR"), "TEST128").
+		WithCallbackUrl("https://example.com/callback").
+		WithPaymentConfig(PaymentConfig{Currency: "USD"}).
+		WithSplit(SplitSettlement{SplitType: "fixed", SplitRule: []SplitRule{{VendorId: "V1", Amount: NewMoney(100, "USD")}}})
+
+	var verr *ValidationError
+	assert.True(t, errors.As(req.Validate(), &verr))
+	assert.Equal(t, "payment config currency USD does not match amount currency INR", verr.FieldError("currency"))
+	assert.Equal(t, "currency USD does not match order currency INR", verr.FieldError("split_settlement.0.amount"))
+}
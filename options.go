@@ -0,0 +1,90 @@
+package paytring
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Option configures an Api created by NewClient.
+type Option func(*Api)
+
+// WithBaseURL points the client at another Paytring environment, such as a
+// sandbox or a local mock server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Api) {
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		c.ApiUrl = baseURL
+	}
+}
+
+// WithHTTPClient makes the client send requests through hc. hc is copied,
+// so WithTimeout and WithTransport never modify the caller's client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Api) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout limits how long a single HTTP request may take.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Api) {
+		c.timeout = timeout
+	}
+}
+
+// WithTransport replaces the transport used for requests, e.g. with an
+// instrumented http.RoundTripper.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Api) {
+		c.transport = transport
+	}
+}
+
+// WithUserAgentSuffix appends suffix to the SDK's User-Agent so requests
+// can be attributed to the calling application.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(c *Api) {
+		if suffix != "" {
+			c.UserAgent = DefaultUserAgent + " " + suffix
+		}
+	}
+}
+
+// WithClock replaces the clock used to decide whether a card has expired.
+// It is meant for tests.
+func WithClock(now func() time.Time) Option {
+	return func(c *Api) {
+		c.clock = now
+	}
+}
+
+func (c *Api) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+func (c *Api) newHTTPClient() *resty.Client {
+	var client *resty.Client
+	if c.httpClient != nil {
+		hc := *c.httpClient
+		client = resty.NewWithClient(&hc)
+	} else {
+		client = resty.New()
+	}
+
+	if c.transport != nil {
+		client.SetTransport(c.transport)
+	}
+	if c.timeout > 0 {
+		client.SetTimeout(c.timeout)
+	}
+
+	return client
+}
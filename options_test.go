@@ -0,0 +1,55 @@
+package paytring
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestNewClientDefaults(t *testing.T) {
+	client := NewClient(apiKey, apiSecret)
+	assert.Equal(t, DefaultApiUrl, client.ApiUrl)
+	assert.Equal(t, DefaultUserAgent, client.UserAgent)
+}
+
+func TestNewClientOptions(t *testing.T) {
+	var userAgent, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		path = r.URL.Path
+		w.Write([]byte(`{"status":true,"vpa":"test@upi","valid":true}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	hc := &http.Client{}
+
+	client := NewClient(apiKey, apiSecret,
+		WithBaseURL(server.URL+"/api"),
+		WithHTTPClient(hc),
+		WithTransport(transport),
+		WithTimeout(time.Second),
+		WithUserAgentSuffix("checkout/1.2"),
+	)
+
+	info, err := client.ValidateVPA("test@upi")
+	assert.NoError(t, err)
+	assert.True(t, info.Valid)
+	assert.Equal(t, "/api/v1/info/vpa", path)
+	assert.Equal(t, "paytring-go-sdk/0 checkout/1.2", userAgent)
+	assert.Equal(t, 1, transport.calls)
+	assert.Nil(t, hc.Transport)
+	assert.Zero(t, hc.Timeout)
+}
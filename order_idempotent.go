@@ -0,0 +1,132 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CreateOutcome reports which path CreateOrderIdempotent took.
+type CreateOutcome string
+
+const (
+	// OutcomeCreated means the create call itself succeeded.
+	OutcomeCreated CreateOutcome = "created"
+	// OutcomeRecovered means the create call failed ambiguously and the
+	// order Paytring had already accepted was found by its receipt id.
+	OutcomeRecovered CreateOutcome = "recovered"
+)
+
+// IdempotentOrder is returned by CreateOrderIdempotent. Exactly one of
+// Created and Existing is set, depending on Outcome.
+type IdempotentOrder struct {
+	Outcome  CreateOutcome
+	Created  *CreatedOrder
+	Existing *Order
+	// Attempts is the number of create calls that were made.
+	Attempts int
+}
+
+// OrderId returns the id of the order regardless of how it was obtained.
+func (o *IdempotentOrder) OrderId() string {
+	if o.Existing != nil {
+		return o.Existing.OrderId
+	}
+	if o.Created != nil {
+		return o.Created.OrderId
+	}
+	return ""
+}
+
+func (c *Api) CreateOrderIdempotent(
+	amount Money,
+	receiptId string,
+	callbackUrl string,
+	customer Customer,
+	opts ...interface{},
+) (*IdempotentOrder, error) {
+	return c.CreateOrderIdempotentCtx(context.Background(), amount, receiptId, callbackUrl, customer, opts...)
+}
+
+// CreateOrderIdempotentCtx is CreateOrderCtx with the receipt based
+// deduplication of SubmitOrderIdempotentCtx.
+func (c *Api) CreateOrderIdempotentCtx(
+	ctx context.Context,
+	amount Money,
+	receiptId string,
+	callbackUrl string,
+	customer Customer,
+	opts ...interface{},
+) (*IdempotentOrder, error) {
+
+	req, err := newOrderRequest(amount, receiptId, callbackUrl, customer, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.SubmitOrderIdempotentCtx(ctx, req)
+}
+
+func (c *Api) SubmitOrderIdempotent(req *OrderRequest) (*IdempotentOrder, error) {
+	return c.SubmitOrderIdempotentCtx(context.Background(), req)
+}
+
+// SubmitOrderIdempotentCtx creates an order at most once per receipt id.
+// When the create call fails in a way that leaves it unclear whether
+// Paytring accepted the order, such as a timeout or a 5xx response, the
+// order is looked up with FetchOrderByReceipt and returned if it exists.
+// Otherwise the create is retried following c.RetryPolicy. The create call
+// itself is never retried by post, even with an idempotency key in ctx, so
+// every attempt is followed by a lookup.
+func (c *Api) SubmitOrderIdempotentCtx(ctx context.Context, req *OrderRequest) (*IdempotentOrder, error) {
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		created, err := c.SubmitOrderCtx(withoutRetries(ctx), req)
+		if err == nil {
+			return &IdempotentOrder{Outcome: OutcomeCreated, Created: created, Attempts: attempt}, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if !isAmbiguousCreateError(err) {
+			return nil, err
+		}
+
+		existing, lookupErr := c.FetchOrderByReceiptCtx(ctx, req.ReceiptId)
+		if lookupErr == nil && existing.OrderId != "" {
+			return &IdempotentOrder{Outcome: OutcomeRecovered, Existing: existing, Attempts: attempt}, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		// Only a definite answer from Paytring tells us the order does not
+		// exist. Anything else leaves the first create in doubt.
+		var apiErr *APIError
+		if lookupErr != nil && !(errors.As(lookupErr, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError) {
+			return nil, fmt.Errorf("CreateOrder outcome unknown for receipt %s: %w", req.ReceiptId, err)
+		}
+
+		if attempt >= c.RetryPolicy.MaxAttempts {
+			return nil, err
+		}
+		if err := sleepContext(ctx, c.RetryPolicy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isAmbiguousCreateError reports whether Paytring may have created the order
+// even though CreateOrder returned err.
+func isAmbiguousCreateError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.FieldError("receipt_id") != ""
+	}
+	return IsNetworkError(err)
+}
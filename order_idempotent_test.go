@@ -0,0 +1,113 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func idempotencyServer(t *testing.T, create func(attempt int) (int, string), lookup func() (int, string)) (*httptest.Server, *[]string) {
+	var paths []string
+	creates := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var status int
+		var body string
+		switch r.URL.Path {
+		case "/v2/order/create":
+			creates++
+			status, body = create(creates)
+		case "/v2/order/fetch/receipt":
+			status, body = lookup()
+		default:
+			t.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	return server, &paths
+}
+
+func newIdempotencyClient(url string) *Api {
+	return NewClient(apiKey, apiSecret, WithBaseURL(url), WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+	}))
+}
+
+func TestCreateOrderIdempotentRecovers(t *testing.T) {
+	server, paths := idempotencyServer(t,
+		func(int) (int, string) { return http.StatusBadGateway, `{"status":false}` },
+		func() (int, string) {
+			return http.StatusOK, `{"status":true,"order":{"order_id":"771606428862383868","receipt_id":"TEST123"}}`
+		},
+	)
+	defer server.Close()
+
+	client := newIdempotencyClient(server.URL)
+	result, err := client.CreateOrderIdempotent(NewMoney(1000, "INR"), "TEST123", "https://example.com/callback", Customer{Name: "John Doe"})
+	assert.NoError(t, err)
+	assert.Equal(t, OutcomeRecovered, result.Outcome)
+	assert.Equal(t, "771606428862383868", result.OrderId())
+	assert.Nil(t, result.Created)
+	assert.Equal(t, []string{"/v2/order/create", "/v2/order/fetch/receipt"}, *paths)
+}
+
+func TestCreateOrderIdempotentRetriesWhenNotFound(t *testing.T) {
+	server, _ := idempotencyServer(t,
+		func(attempt int) (int, string) {
+			if attempt == 1 {
+				return http.StatusServiceUnavailable, `{"status":false}`
+			}
+			return http.StatusOK, `{"status":true,"order_id":"771606428862383869","url":"https://example.com/pay"}`
+		},
+		func() (int, string) {
+			return http.StatusNotFound, `{"status":false,"error":{"message":"Order not found"}}`
+		},
+	)
+	defer server.Close()
+
+	client := newIdempotencyClient(server.URL)
+	result, err := client.CreateOrderIdempotent(NewMoney(1000, "INR"), "TEST124", "https://example.com/callback", Customer{Name: "John Doe"})
+	assert.NoError(t, err)
+	assert.Equal(t, OutcomeCreated, result.Outcome)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, "771606428862383869", result.OrderId())
+}
+
+func TestSubmitOrderIdempotentDoesNotNestRetries(t *testing.T) {
+	client, server := newTestClient(t)
+	client.RetryPolicy = fastRetries
+	server.FailNext("v2/order/create", paytringtest.Failure{Status: http.StatusServiceUnavailable, Times: 10})
+
+	ctx := ContextWithIdempotencyKey(context.Background(), "order-TEST126")
+	_, err := client.SubmitOrderIdempotentCtx(ctx, NewOrder(NewMoney(1000, "INR"), "TEST126").
+		WithCallbackUrl("https://example.com/callback"))
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, 3, server.Calls("v2/order/create"))
+	assert.Equal(t, 3, server.Calls("v2/order/fetch/receipt"))
+}
+
+func TestCreateOrderIdempotentValidationError(t *testing.T) {
+	server, paths := idempotencyServer(t,
+		func(int) (int, string) {
+			return http.StatusUnprocessableEntity, `{"status":false,"error":{"message":"{\"phone\":[\"The phone is invalid.\"]}"}}`
+		},
+		nil,
+	)
+	defer server.Close()
+
+	client := newIdempotencyClient(server.URL)
+	_, err := client.CreateOrderIdempotent(NewMoney(1000, "INR"), "TEST125", "https://example.com/callback", Customer{Phone: "1"})
+	assert.EqualError(t, err, "The phone is invalid.")
+	assert.Equal(t, []string{"/v2/order/create"}, *paths)
+}
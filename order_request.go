@@ -0,0 +1,327 @@
+package paytring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// OrderRequest describes an order to create with SubmitOrder. Build one with
+// NewOrder and the With methods, or fill the fields directly:
+//
+//	req := paytring.NewOrder(paytring.NewMoney(1000, "INR"), "receipt-42").
+//		WithCallbackUrl("https://example.com/callback").
+//		WithCustomer(customer).
+//		WithBilling(billing)
+//	order, err := client.SubmitOrder(req)
+type OrderRequest struct {
+	Amount          Money
+	ReceiptId       string
+	CallbackUrl     string
+	Customer        Customer
+	PaymentConfig   *PaymentConfig
+	BillingAddress  *BillingAddress
+	ShippingAddress *ShippingAddress
+	Notes           *Notes
+	Tpv             []Tpv
+	SplitSettlement *SplitSettlement
+
+	// conflicts collects options that were set more than once.
+	conflicts []string
+}
+
+// NewOrder starts an OrderRequest for amount identified by receiptId.
+func NewOrder(amount Money, receiptId string) *OrderRequest {
+	return &OrderRequest{Amount: amount, ReceiptId: receiptId}
+}
+
+func (r *OrderRequest) WithCallbackUrl(callbackUrl string) *OrderRequest {
+	if r.CallbackUrl != "" && r.CallbackUrl != callbackUrl {
+		r.conflicts = append(r.conflicts, "callback_url")
+	}
+	r.CallbackUrl = callbackUrl
+	return r
+}
+
+func (r *OrderRequest) WithCustomer(customer Customer) *OrderRequest {
+	r.Customer = customer
+	return r
+}
+
+// WithCustomerId makes the order for a customer created with
+// CreateCustomer, whose saved name, email and phone Paytring fills in.
+func (r *OrderRequest) WithCustomerId(customerId string) *OrderRequest {
+	r.Customer.Id = customerId
+	return r
+}
+
+func (r *OrderRequest) WithPaymentConfig(config PaymentConfig) *OrderRequest {
+	if r.PaymentConfig != nil {
+		r.conflicts = append(r.conflicts, "payment_config")
+	}
+	r.PaymentConfig = &config
+	return r
+}
+
+func (r *OrderRequest) WithBilling(address BillingAddress) *OrderRequest {
+	if r.BillingAddress != nil {
+		r.conflicts = append(r.conflicts, "billing_address")
+	}
+	r.BillingAddress = &address
+	return r
+}
+
+func (r *OrderRequest) WithShipping(address ShippingAddress) *OrderRequest {
+	if r.ShippingAddress != nil {
+		r.conflicts = append(r.conflicts, "shipping_address")
+	}
+	r.ShippingAddress = &address
+	return r
+}
+
+func (r *OrderRequest) WithNotes(notes Notes) *OrderRequest {
+	if r.Notes != nil {
+		r.conflicts = append(r.conflicts, "notes")
+	}
+	r.Notes = &notes
+	return r
+}
+
+// WithTPV restricts payment to the given bank accounts (third party
+// verification). It may be called more than once to add accounts.
+func (r *OrderRequest) WithTPV(accounts ...Tpv) *OrderRequest {
+	r.Tpv = append(r.Tpv, accounts...)
+	return r
+}
+
+func (r *OrderRequest) WithSplit(split SplitSettlement) *OrderRequest {
+	if r.SplitSettlement != nil {
+		r.conflicts = append(r.conflicts, "split_settlement")
+	}
+	r.SplitSettlement = &split
+	return r
+}
+
+// Validate checks the whole request without sending it. The returned error
+// is a *ValidationError listing every problem found.
+func (r *OrderRequest) Validate() error {
+	verr := &ValidationError{}
+
+	for _, field := range r.conflicts {
+		verr.add(field, "set more than once")
+	}
+
+	if !r.Amount.IsPositive() {
+		verr.add("amount", "must be greater than zero")
+	}
+	if r.ReceiptId == "" {
+		verr.add("receipt_id", "is required")
+	}
+	if r.CallbackUrl == "" {
+		verr.add("callback_url", "is required")
+	} else if !validCallbackURL(r.CallbackUrl) {
+		verr.add("callback_url", "must be an absolute http or https URL")
+	}
+
+	if _, err := CurrencyExponent(r.currency()); err != nil {
+		verr.add("currency", "must be a three letter ISO 4217 code")
+	}
+
+	if config := r.PaymentConfig; config != nil {
+		if config.Currency != "" && r.Amount.Currency != "" && !strings.EqualFold(config.Currency, r.Amount.Currency) {
+			verr.add("currency", "payment config currency %s does not match amount currency %s", config.Currency, r.Amount.Currency)
+		}
+		// A pool lets Paytring pick the gateway, pinning one as well
+		// would leave it unclear which routing wins.
+		if config.Pg != "" && config.PgPoolId != "" {
+			verr.add("pg_pool_id", "cannot be combined with pg")
+		}
+	}
+
+	for i, account := range r.Tpv {
+		if account.AccountNumber == "" {
+			verr.add(fmt.Sprintf("tpv.%d.account_number", i), "is required")
+		}
+		if account.Ifsc == "" {
+			verr.add(fmt.Sprintf("tpv.%d.ifsc", i), "is required")
+		}
+	}
+
+	if split := r.SplitSettlement; split != nil {
+		split.validate(verr, NewMoney(r.Amount.Amount, r.currency()))
+	}
+
+	return verr.err()
+}
+
+// currency is the currency of the order: the one of Amount, else the one
+// of PaymentConfig, else INR.
+func (r *OrderRequest) currency() string {
+	if r.PaymentConfig != nil {
+		return currencyOrDefault(r.Amount.Currency, r.PaymentConfig.Currency)
+	}
+	return currencyOrDefault(r.Amount.Currency)
+}
+
+// body builds the create order payload for key.
+func (r *OrderRequest) body(key string) map[string]interface{} {
+
+	var paymentConfig PaymentConfig
+	var billingAddress BillingAddress
+	var shippingAddress ShippingAddress
+	var splitSettlement SplitSettlement
+
+	if r.PaymentConfig != nil {
+		paymentConfig = *r.PaymentConfig
+	}
+	if r.BillingAddress != nil {
+		billingAddress = *r.BillingAddress
+	}
+	if r.ShippingAddress != nil {
+		shippingAddress = *r.ShippingAddress
+	}
+	if r.SplitSettlement != nil {
+		splitSettlement = *r.SplitSettlement
+	}
+
+	requestBody := map[string]interface{}{
+		"key":          key,
+		"receipt_id":   r.ReceiptId,
+		"amount":       r.Amount.Minor(),
+		"callback_url": r.CallbackUrl,
+	}
+
+	if r.Customer.Id != "" {
+		requestBody["customer_id"] = r.Customer.Id
+		addToMapIfNotBlank(requestBody, "cname", r.Customer.Name)
+		addToMapIfNotBlank(requestBody, "phone", r.Customer.Phone)
+		addToMapIfNotBlank(requestBody, "email", r.Customer.Email)
+	} else {
+		requestBody["cname"] = r.Customer.Name
+		requestBody["phone"] = r.Customer.Phone
+		requestBody["email"] = r.Customer.Email
+	}
+
+	requestBody["currency"] = r.currency()
+
+	if paymentConfig.Pg != "" {
+		requestBody["pg"] = paymentConfig.Pg
+	}
+
+	if paymentConfig.PgPoolId != "" {
+		requestBody["pg_pool_id"] = paymentConfig.PgPoolId
+	}
+
+	if !paymentConfig.AutoCapture {
+		requestBody["auto_capture"] = "false"
+	} else {
+		requestBody["auto_capture"] = "true"
+	}
+
+	billingAddressMap := addressMap(billingAddress)
+	shippingAddressMap := addressMap(BillingAddress(shippingAddress))
+
+	notes := notesMap(r.Notes)
+
+	var tpvMapMap []map[string]interface{}
+	for _, tpvAccount := range r.Tpv {
+		tpvMap := make(map[string]interface{})
+		addToMapIfNotBlank(tpvMap, "account_number", tpvAccount.AccountNumber)
+		addToMapIfNotBlank(tpvMap, "name", tpvAccount.Name)
+		addToMapIfNotBlank(tpvMap, "ifsc", tpvAccount.Ifsc)
+		tpvMapMap = append(tpvMapMap, tpvMap)
+	}
+
+	if splitSettlement.SplitType != "" {
+		requestBody["split_type"] = string(splitSettlement.SplitType)
+	}
+
+	var splitSettlementMap []map[string]interface{}
+	for _, splitRule := range splitSettlement.SplitRule {
+		var splitSettlementRuleMap = make(map[string]interface{})
+		addToMapIfNotBlank(splitSettlementRuleMap, "vendor_id", splitRule.VendorId)
+		if splitSettlement.SplitType == SplitPercentage {
+			splitSettlementRuleMap["percentage"] = fmt.Sprintf("%d.%02d", splitRule.BasisPoints/100, splitRule.BasisPoints%100)
+		} else {
+			addToMapIfNotBlank(splitSettlementRuleMap, "amount", splitRule.Amount.Amount)
+		}
+		splitSettlementMap = append(splitSettlementMap, splitSettlementRuleMap)
+	}
+
+	if len(splitSettlementMap) > 0 {
+		requestBody["split_settlement"] = splitSettlementMap
+	}
+	if len(billingAddressMap) > 0 {
+		requestBody["billing_address"] = billingAddressMap
+	}
+	if len(shippingAddressMap) > 0 {
+		requestBody["shipping_address"] = shippingAddressMap
+	}
+	if len(notes) > 0 {
+		requestBody["notes"] = notes
+	}
+	if len(tpvMapMap) > 0 {
+		requestBody["tpv"] = tpvMapMap
+	}
+
+	requestBody["hash"] = "none"
+
+	return requestBody
+}
+
+// newOrderRequest turns the variadic options of CreateOrder into an
+// OrderRequest. Options of an unsupported type are reported instead of
+// being ignored.
+func newOrderRequest(amount Money, receiptId string, callbackUrl string, customer Customer, opts []interface{}) (*OrderRequest, error) {
+	req := NewOrder(amount, receiptId).
+		WithCallbackUrl(callbackUrl).
+		WithCustomer(customer)
+
+	verr := &ValidationError{}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case PaymentConfig:
+			req.WithPaymentConfig(v)
+		case BillingAddress:
+			req.WithBilling(v)
+		case ShippingAddress:
+			req.WithShipping(v)
+		case Notes:
+			req.WithNotes(v)
+		case []Tpv:
+			req.WithTPV(v...)
+		case SplitSettlement:
+			req.WithSplit(v)
+		default:
+			verr.add("options", "unsupported option of type %T", opt)
+		}
+	}
+
+	return req, verr.err()
+}
+
+func (c *Api) SubmitOrder(req *OrderRequest) (*CreatedOrder, error) {
+	return c.SubmitOrderCtx(context.Background(), req)
+}
+
+// SubmitOrderCtx validates req and creates the order.
+func (c *Api) SubmitOrderCtx(ctx context.Context, req *OrderRequest) (*CreatedOrder, error) {
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, response, err := c.post(ctx, "CreateOrder", "v2/order/create", c.MakeAuthHeader(), req.body(c.ApiKey))
+	if err != nil {
+		return nil, err
+	}
+
+	var order CreatedOrder
+	if err := decodeResponse(body, "", response, &order); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for CreateOrder: %w", err)
+	}
+
+	c.guard.observeCreated(&order, req.Amount)
+
+	return &order, nil
+}
@@ -0,0 +1,104 @@
+package paytring
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderRequestValidate(t *testing.T) {
+	req := NewOrder(NewMoney(0, "INR"), "").
+		WithCallbackUrl("/relative").
+		WithBilling(BillingAddress{City: "Mumbai"}).
+		WithBilling(BillingAddress{City: "Pune"}).
+		WithSplit(SplitSettlement{SplitRule: []SplitRule{{VendorId: "V1", Amount: NewMoney(100, "INR")}}})
+
+	err := req.Validate()
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "must be greater than zero", verr.FieldError("amount"))
+	assert.Equal(t, "is required", verr.FieldError("receipt_id"))
+	assert.Equal(t, "must be an absolute http or https URL", verr.FieldError("callback_url"))
+	assert.Equal(t, "set more than once", verr.FieldError("billing_address"))
+	assert.NotEmpty(t, verr.FieldError("split_type"))
+}
+
+func TestCreateOrderRejectsUnknownOptions(t *testing.T) {
+	client := NewClient(apiKey, apiSecret, WithBaseURL("http://127.0.0.1:0"))
+
+	_, err := client.CreateOrder(NewMoney(1000, "INR"), "TEST123", "https://example.com/callback", Customer{}, &PaymentConfig{Currency: "USD"})
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "unsupported option of type *paytring.PaymentConfig", verr.FieldError("options"))
+
+	_, err = client.CreateOrder(NewMoney(1000, "INR"), "TEST123", "https://example.com/callback", Customer{}, PaymentConfig{}, PaymentConfig{})
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "set more than once", verr.FieldError("payment_config"))
+}
+
+func TestSubmitOrder(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"status":true,"order_id":"771606428862383868","url":"https://example.com/pay"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+
+	req := NewOrder(NewMoney(1000, "USD"), "TEST123").
+		WithCallbackUrl("https://example.com/callback").
+		WithCustomer(Customer{Name: "John Doe", Email: "john.doe@example.com", Phone: "1234567890"}).
+		WithPaymentConfig(PaymentConfig{Currency: "USD", AutoCapture: true}).
+		WithNotes(Notes{Udf1: "cart-9"}).
+		WithTPV(Tpv{AccountNumber: "0001", Name: "John Doe", Ifsc: "HDFC0000001"})
+
+	order, err := client.SubmitOrder(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "771606428862383868", order.OrderId)
+
+	assert.Equal(t, "1000", sent["amount"])
+	assert.Equal(t, "USD", sent["currency"])
+	assert.Equal(t, "true", sent["auto_capture"])
+	assert.Equal(t, "John Doe", sent["cname"])
+	assert.Equal(t, map[string]interface{}{"udf1": "cart-9"}, sent["notes"])
+	assert.Len(t, sent["tpv"], 1)
+}
+
+func TestSubmitOrderWithPgPool(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"status":true,"order_id":"771606428862383868","url":"https://example.com/pay","pg":"razorpay","pg_pool_id":"pool_high_value"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL))
+
+	req := NewOrder(NewMoney(5000000, "INR"), "TEST126").
+		WithCallbackUrl("https://example.com/callback").
+		WithPaymentConfig(PaymentConfig{PgPoolId: "pool_high_value"})
+
+	order, err := client.SubmitOrder(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "pool_high_value", sent["pg_pool_id"])
+	assert.NotContains(t, sent, "pg")
+	assert.Equal(t, "razorpay", order.Pg)
+	assert.Equal(t, "pool_high_value", order.PgPoolId)
+}
+
+func TestPgAndPgPoolConflict(t *testing.T) {
+	req := NewOrder(NewMoney(1000, "INR"), "TEST127").
+		WithCallbackUrl("https://example.com/callback").
+		WithPaymentConfig(PaymentConfig{Pg: "razorpay", PgPoolId: "pool_high_value"})
+
+	var verr *ValidationError
+	assert.True(t, errors.As(req.Validate(), &verr))
+	assert.Equal(t, "cannot be combined with pg", verr.FieldError("pg_pool_id"))
+}
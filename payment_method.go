@@ -0,0 +1,384 @@
+package paytring
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentMethod is how PayOrder pays an order: Netbanking, Wallet,
+// CardPayment, CardEMI, CardlessEMI, BNPL, UPICollect, UPIIntent or UPIQR.
+type PaymentMethod interface {
+	// apply adds the method's fields to body, reporting what is wrong with
+	// them in verr.
+	apply(body map[string]interface{}, verr *ValidationError, now time.Time)
+}
+
+var (
+	bankCodePattern = regexp.MustCompile(`^[A-Z]{4}$`)
+	providerPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	mobilePattern   = regexp.MustCompile(`^[6-9]\d{9}$`)
+)
+
+// EMITenures are the card EMI tenures, in months, Paytring offers.
+var EMITenures = []int{3, 6, 9, 12, 18, 24}
+
+// Netbanking pays from the bank named by BankCode, the first four letters
+// of its IFSC, e.g. "HDFC" or "SBIN".
+type Netbanking struct {
+	BankCode string
+}
+
+func (m Netbanking) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	if !bankCodePattern.MatchString(m.BankCode) {
+		verr.add("code", "must be a four letter bank code such as HDFC")
+	}
+	body["method"] = "netbanking"
+	body["code"] = m.BankCode
+}
+
+// Wallet pays from a wallet such as "paytm" or "amazonpay". Some wallets
+// need the Phone the wallet is registered to.
+type Wallet struct {
+	Provider string
+	Phone    string
+}
+
+func (m Wallet) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	addProvider(body, verr, m.Provider)
+	if m.Phone != "" {
+		addMobile(body, verr, m.Phone)
+	}
+	body["method"] = "wallet"
+}
+
+// CardPayment pays with a card, by its details or a saved card token.
+type CardPayment struct {
+	Card PaymentData
+}
+
+func (m CardPayment) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	card, err := cardFields(m.Card, now)
+	verr.merge(err)
+	body["method"] = "card"
+	body["code"] = ""
+	body["card"] = card
+}
+
+// CardEMI pays with a card in TenureMonths instalments, one of EMITenures.
+// With NoCost the merchant bears the interest.
+type CardEMI struct {
+	Card         PaymentData
+	TenureMonths int
+	NoCost       bool
+}
+
+func (m CardEMI) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	card, err := cardFields(m.Card, now)
+	verr.merge(err)
+	if !validTenure(m.TenureMonths) {
+		verr.add("emi.tenure", "must be one of %s months", tenureList())
+	}
+
+	emiType := "standard"
+	if m.NoCost {
+		emiType = "no_cost"
+	}
+	body["method"] = "emi"
+	body["code"] = "card"
+	body["card"] = card
+	body["emi"] = map[string]interface{}{
+		"tenure": strconv.Itoa(m.TenureMonths),
+		"type":   emiType,
+	}
+}
+
+// CardlessEMI pays in instalments from a lender such as "zestmoney",
+// without a card. The customer is identified by Phone. A zero
+// TenureMonths lets the customer choose on the lender's page.
+type CardlessEMI struct {
+	Provider     string
+	Phone        string
+	TenureMonths int
+}
+
+func (m CardlessEMI) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	addProvider(body, verr, m.Provider)
+	addMobile(body, verr, m.Phone)
+	if m.TenureMonths < 0 {
+		verr.add("emi.tenure", "must not be negative")
+	}
+	body["method"] = "cardless_emi"
+	if m.TenureMonths > 0 {
+		body["emi"] = map[string]interface{}{"tenure": strconv.Itoa(m.TenureMonths)}
+	}
+}
+
+// BNPL pays later through a provider such as "simpl" or "lazypay". The
+// customer is identified by Phone.
+type BNPL struct {
+	Provider string
+	Phone    string
+}
+
+func (m BNPL) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	addProvider(body, verr, m.Provider)
+	addMobile(body, verr, m.Phone)
+	body["method"] = "bnpl"
+}
+
+// UPICollect sends a payment request to Vpa for the customer to approve
+// in their UPI app.
+type UPICollect struct {
+	Vpa string
+}
+
+func (m UPICollect) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	if m.Vpa == "" {
+		verr.add("vpa", "is required for UPI collect")
+	} else if !strings.Contains(m.Vpa, "@") {
+		verr.add("vpa", "must look like name@bank")
+	}
+	body["method"] = "upi"
+	body["code"] = "collect"
+	body["vpa"] = m.Vpa
+}
+
+// UPIIntent returns a deep link that opens a UPI app on the customer's
+// phone, see NextAction.
+type UPIIntent struct{}
+
+func (m UPIIntent) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	body["method"] = "upi"
+	body["code"] = "intent"
+}
+
+// UPIQR returns a QR code for the customer to scan with any UPI app, see
+// NextAction.
+type UPIQR struct{}
+
+func (m UPIQR) apply(body map[string]interface{}, verr *ValidationError, now time.Time) {
+	body["method"] = "upi"
+	body["code"] = "qr"
+}
+
+func addProvider(body map[string]interface{}, verr *ValidationError, provider string) {
+	if provider == "" {
+		verr.add("code", "is required")
+	} else if !providerPattern.MatchString(provider) {
+		verr.add("code", "must be a lower case provider code such as paytm")
+	}
+	body["code"] = provider
+}
+
+func addMobile(body map[string]interface{}, verr *ValidationError, phone string) {
+	digits := strings.TrimPrefix(strings.ReplaceAll(phone, " ", ""), "+91")
+	if phone == "" {
+		verr.add("phone", "is required")
+	} else if !mobilePattern.MatchString(digits) {
+		verr.add("phone", "must be a 10 digit mobile number")
+	}
+	body["phone"] = digits
+}
+
+func validTenure(months int) bool {
+	for _, tenure := range EMITenures {
+		if tenure == months {
+			return true
+		}
+	}
+	return false
+}
+
+// tenureList formats EMITenures as "3, 6, 9, 12, 18 or 24".
+func tenureList() string {
+	tenures := make([]string, 0, len(EMITenures))
+	for _, tenure := range EMITenures {
+		tenures = append(tenures, strconv.Itoa(tenure))
+	}
+	last := len(tenures) - 1
+	return strings.Join(tenures[:last], ", ") + " or " + tenures[last]
+}
+
+// cardFields validates data and returns the card payload for it, by token
+// when data carries one and by card details otherwise.
+func cardFields(data PaymentData, now time.Time) (map[string]interface{}, error) {
+	if data.Token != "" {
+		card := map[string]interface{}{
+			"token":      data.Token,
+			"cryptogram": data.Cryptogram,
+		}
+		addToMapIfNotBlank(card, "holder_name", data.HolderName)
+		addToMapIfNotBlank(card, "cvv", data.Cvv)
+		return card, validateTokenData(data)
+	}
+
+	return map[string]interface{}{
+		"holder_name":  data.HolderName,
+		"number":       cardDigits(data.CardNumber),
+		"expiry_month": data.ExpiryMonth,
+		"expiry_year":  data.ExpiryYear,
+		"cvv":          data.Cvv,
+	}, ValidateCardData(data, now)
+}
+
+// NextActionType is what the customer has to do to complete a payment.
+type NextActionType string
+
+const (
+	// NextNone means there is nothing to show, e.g. for UPI collect the
+	// customer approves the request in their app.
+	NextNone     NextActionType = "none"
+	NextRedirect NextActionType = "redirect"
+	NextIntent   NextActionType = "intent"
+	NextQR       NextActionType = "qr"
+)
+
+// NextAction is the step that completes a processed order.
+type NextAction struct {
+	Type NextActionType
+	// Url is the page to send the customer to for NextRedirect.
+	Url string
+	// Uri is the UPI deep link to open for NextIntent, or to render as a
+	// QR code for NextQR. UPI is Uri decoded.
+	Uri string
+	UPI *UPIPayload
+}
+
+// NextAction tells what the customer has to do to complete the payment.
+// It fails when Paytring returned an intent or QR code that cannot be
+// decoded.
+func (p *ProcessedOrder) NextAction() (*NextAction, error) {
+	switch {
+	case p.IntentUrl != "":
+		payload, err := ParseUPIURI(p.IntentUrl)
+		if err != nil {
+			return nil, err
+		}
+		return &NextAction{Type: NextIntent, Uri: p.IntentUrl, UPI: payload}, nil
+	case p.QrString != "":
+		payload, err := DecodeUPIQR(p.QrString)
+		if err != nil {
+			return nil, err
+		}
+		return &NextAction{Type: NextQR, Uri: payload.Raw, UPI: payload}, nil
+	case p.Url != "":
+		return &NextAction{Type: NextRedirect, Url: p.Url}, nil
+	}
+	return &NextAction{Type: NextNone}, nil
+}
+
+// UPIPayload is a decoded UPI deep link, the upi://pay URI that UPI intents
+// open and UPI QR codes contain.
+type UPIPayload struct {
+	// Payee is the VPA being paid (pa) and PayeeName its name (pn).
+	Payee     string
+	PayeeName string
+	// Amount is zero when the link leaves it to the customer.
+	Amount          Money
+	TransactionRef  string
+	TransactionNote string
+	MerchantCode    string
+	Raw             string
+}
+
+// upiSchemes are the deep link schemes ParseUPIURI accepts: upi itself and
+// those of UPI apps that register their own.
+var upiSchemes = map[string]bool{
+	"upi":     true,
+	"tez":     true,
+	"phonepe": true,
+	"paytmmp": true,
+	"bhim":    true,
+	"credpay": true,
+}
+
+// ParseUPIURI decodes a UPI deep link: upi://pay and the app specific
+// variants such as tez://upi/pay, which carry the same query. Links with
+// other schemes, web URLs included, are rejected.
+func ParseUPIURI(uri string) (*UPIPayload, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPI URI: %w", err)
+	}
+	if !upiSchemes[strings.ToLower(u.Scheme)] {
+		return nil, fmt.Errorf("invalid UPI URI %q: unsupported scheme %q", Redact(uri), u.Scheme)
+	}
+	if target := strings.TrimSuffix(u.Host+u.Path, "/"); target != "pay" && target != "upi/pay" {
+		return nil, fmt.Errorf("invalid UPI URI %q: not a pay link", Redact(uri))
+	}
+
+	query := u.Query()
+	payload := &UPIPayload{
+		Payee:           query.Get("pa"),
+		PayeeName:       query.Get("pn"),
+		TransactionRef:  query.Get("tr"),
+		TransactionNote: query.Get("tn"),
+		MerchantCode:    query.Get("mc"),
+		Raw:             strings.TrimSpace(uri),
+	}
+	if !strings.Contains(payload.Payee, "@") {
+		return nil, fmt.Errorf("invalid UPI URI %q: missing payee address", Redact(uri))
+	}
+
+	if am := query.Get("am"); am != "" {
+		amount, err := ParseMoney(am, currencyOrDefault(query.Get("cu")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPI URI amount: %w", err)
+		}
+		payload.Amount = amount
+	}
+
+	return payload, nil
+}
+
+// DecodeUPIQR decodes the content of a UPI QR code, given as the deep link
+// itself or base64 encoded.
+func DecodeUPIQR(qr string) (*UPIPayload, error) {
+	qr = strings.TrimSpace(qr)
+	if strings.Contains(qr, "://") {
+		return ParseUPIURI(qr)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(qr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPI QR code: %w", err)
+	}
+	return ParseUPIURI(string(decoded))
+}
+
+func (c *Api) PayOrder(orderId string, method PaymentMethod, device string) (*ProcessedOrder, error) {
+	return c.PayOrderCtx(context.Background(), orderId, method, device)
+}
+
+// PayOrderCtx validates method and processes the order with it, like
+// ProcessOrderCtx with typed payment details. Use NextAction on the result
+// to complete the payment.
+func (c *Api) PayOrderCtx(ctx context.Context, orderId string, method PaymentMethod, device string) (*ProcessedOrder, error) {
+
+	requestBody := map[string]interface{}{
+		"key":      c.ApiKey,
+		"order_id": orderId,
+	}
+
+	if device != "" {
+		requestBody["device"] = device
+	}
+
+	verr := &ValidationError{}
+	if method == nil {
+		verr.add("method", "is required")
+	} else {
+		method.apply(requestBody, verr, c.now())
+	}
+	if err := verr.err(); err != nil {
+		return nil, err
+	}
+
+	return c.process(ctx, requestBody)
+}
@@ -0,0 +1,116 @@
+package paytring
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentMethodValidation(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+	card := PaymentData{CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "123"}
+
+	cases := []struct {
+		method PaymentMethod
+		field  string
+		want   string
+	}{
+		{Netbanking{BankCode: "hdfc"}, "code", "must be a four letter bank code such as HDFC"},
+		{Wallet{}, "code", "is required"},
+		{Wallet{Provider: "paytm", Phone: "12345"}, "phone", "must be a 10 digit mobile number"},
+		{CardEMI{Card: card, TenureMonths: 5}, "emi.tenure", "must be one of 3, 6, 9, 12, 18 or 24 months"},
+		{CardEMI{Card: PaymentData{Token: "T1"}, TenureMonths: 6}, "card.cryptogram", "is required to pay with a token"},
+		{CardlessEMI{Provider: "zestmoney"}, "phone", "is required"},
+		{BNPL{Provider: "Simpl", Phone: "9876543210"}, "code", "must be a lower case provider code such as paytm"},
+		{UPICollect{Vpa: "johndoe"}, "vpa", "must look like name@bank"},
+	}
+
+	for _, c := range cases {
+		verr := &ValidationError{}
+		c.method.apply(map[string]interface{}{}, verr, now)
+		assert.Equal(t, c.want, verr.FieldError(c.field), "%#v", c.method)
+	}
+
+	body := map[string]interface{}{}
+	verr := &ValidationError{}
+	CardEMI{Card: card, TenureMonths: 6, NoCost: true}.apply(body, verr, now)
+	assert.NoError(t, verr.err())
+	assert.Equal(t, "emi", body["method"])
+	assert.Equal(t, map[string]interface{}{"tenure": "6", "type": "no_cost"}, body["emi"])
+
+	body = map[string]interface{}{}
+	BNPL{Provider: "simpl", Phone: "+91 98765 43210"}.apply(body, verr, now)
+	assert.NoError(t, verr.err())
+	assert.Equal(t, "9876543210", body["phone"])
+}
+
+func TestParseUPIURI(t *testing.T) {
+	payload, err := ParseUPIURI("upi://pay?pa=merchant@okaxis&pn=Merchant%20Ltd&am=499.00&cu=INR&tr=771606428862383869&tn=Order")
+	assert.NoError(t, err)
+	assert.Equal(t, "merchant@okaxis", payload.Payee)
+	assert.Equal(t, "Merchant Ltd", payload.PayeeName)
+	assert.Equal(t, NewMoney(49900, "INR"), payload.Amount)
+	assert.Equal(t, "771606428862383869", payload.TransactionRef)
+
+	payload, err = ParseUPIURI("tez://upi/pay?pa=merchant@okaxis")
+	assert.NoError(t, err)
+	assert.True(t, payload.Amount.IsZero())
+
+	_, err = ParseUPIURI("upi://mandate?pa=merchant@okaxis")
+	assert.Error(t, err)
+	_, err = ParseUPIURI("https://evil.example/pay?pa=merchant@okaxis")
+	assert.ErrorContains(t, err, `unsupported scheme "https"`)
+	_, err = ParseUPIURI("evil://pay?pa=merchant@okaxis")
+	assert.Error(t, err)
+	_, err = ParseUPIURI("upi://repay?pa=merchant@okaxis")
+	assert.Error(t, err)
+	_, err = ParseUPIURI("upi://pay?pn=Merchant")
+	assert.Error(t, err)
+	_, err = ParseUPIURI("upi://pay?pa=merchant@okaxis&am=4.999")
+	assert.Error(t, err)
+
+	qr := base64.StdEncoding.EncodeToString([]byte("upi://pay?pa=merchant@okaxis&am=10"))
+	payload, err = DecodeUPIQR(qr)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(1000, "INR"), payload.Amount)
+
+	_, err = DecodeUPIQR("not a qr code")
+	assert.Error(t, err)
+}
+
+func TestPayOrderNextActions(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	pay := func(receiptId string, method PaymentMethod) *NextAction {
+		created, err := client.CreateOrder(NewMoney(49900, "INR"), receiptId, "https://example.com/callback", Customer{})
+		assert.NoError(t, err)
+		processed, err := client.PayOrder(created.OrderId, method, "")
+		assert.NoError(t, err)
+		next, err := processed.NextAction()
+		assert.NoError(t, err)
+		return next
+	}
+
+	next := pay("TEST_RECEIPT_INTENT", UPIIntent{})
+	assert.Equal(t, NextIntent, next.Type)
+	assert.Equal(t, NewMoney(49900, "INR"), next.UPI.Amount)
+
+	next = pay("TEST_RECEIPT_QR", UPIQR{})
+	assert.Equal(t, NextQR, next.Type)
+	assert.Contains(t, next.Uri, "upi://pay?")
+	assert.Equal(t, "paytring@okaxis", next.UPI.Payee)
+
+	next = pay("TEST_RECEIPT_NETBANKING", Netbanking{BankCode: "HDFC"})
+	assert.Equal(t, NextRedirect, next.Type)
+	assert.NotEmpty(t, next.Url)
+
+	_, err := client.PayOrder("771606428862383000", Wallet{}, "")
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	_, err = client.PayOrder("771606428862383000", nil, "")
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "is required", verr.FieldError("method"))
+}
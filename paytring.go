@@ -2,24 +2,43 @@ package paytring
 
 import (
 	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
-func NewClient(apiKey string, apiSecret string) *Api {
-	return &Api{
+const (
+	DefaultApiUrl    = "https://api.paytring.com/api/"
+	DefaultUserAgent = "paytring-go-sdk/0"
+)
+
+// NewClient returns an Api for the given credentials. Without options it
+// talks to DefaultApiUrl using a plain HTTP client with no timeout.
+func NewClient(apiKey string, apiSecret string, opts ...Option) *Api {
+	c := &Api{
 		ApiKey:        apiKey,
 		ApiSecret:     apiSecret,
-		ApiUrl:        "https://api.paytring.com/api/",
-		UserAgent:     "paytring-go-sdk/0",
-		http:          resty.New(),
+		ApiUrl:        DefaultApiUrl,
+		UserAgent:     DefaultUserAgent,
 		CustomHeaders: map[string]string{},
+		RetryPolicy:   DefaultRetryPolicy(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	c.http = c.newHTTPClient()
+
+	return c
 }
 
 type Api struct {
@@ -29,12 +48,27 @@ type Api struct {
 	UserAgent     string
 	http          *resty.Client
 	CustomHeaders map[string]string
+	RetryPolicy   RetryPolicy
+
+	guard           *orderGuard
+	clock           func() time.Time
+	logger          Logger
+	instrumentation Instrumentation
+	middleware      []Middleware
+
+	httpClient *http.Client
+	transport  http.RoundTripper
+	timeout    time.Duration
 }
 
+// Customer is who an order is for. Set Id to a customer created with
+// CreateCustomer to use its saved details instead of sending Name, Email
+// and Phone again; fields that are set as well override the saved ones.
 type Customer struct {
-	Name  string
-	Email string
-	Phone string
+	Id    string `json:"customer_id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
 }
 
 type PaymentConfig struct {
@@ -44,46 +78,36 @@ type PaymentConfig struct {
 	AutoCapture bool
 }
 
-type SplitRule struct {
-	VendorId string
-	Amount   int64
-}
-
-type SplitSettlement struct {
-	SplitType string
-	SplitRule []SplitRule
-}
-
 type BillingAddress struct {
-	Firstname string
-	Lastname  string
-	Phone     string
-	Line1     string
-	Line2     string
-	City      string
-	State     string
-	Country   string
-	Zipcode   string
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Phone     string `json:"phone"`
+	Line1     string `json:"line1"`
+	Line2     string `json:"line2"`
+	City      string `json:"city"`
+	State     string `json:"state"`
+	Country   string `json:"country"`
+	Zipcode   string `json:"zipcode"`
 }
 
 type ShippingAddress struct {
-	Firstname string
-	Lastname  string
-	Phone     string
-	Line1     string
-	Line2     string
-	City      string
-	State     string
-	Country   string
-	Zipcode   string
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Phone     string `json:"phone"`
+	Line1     string `json:"line1"`
+	Line2     string `json:"line2"`
+	City      string `json:"city"`
+	State     string `json:"state"`
+	Country   string `json:"country"`
+	Zipcode   string `json:"zipcode"`
 }
 
 type Notes struct {
-	Udf1 string
-	Udf2 string
-	Udf3 string
-	Udf4 string
-	Udf5 string
+	Udf1 string `json:"udf1"`
+	Udf2 string `json:"udf2"`
+	Udf3 string `json:"udf3"`
+	Udf4 string `json:"udf4"`
+	Udf5 string `json:"udf5"`
 }
 
 type Tpv struct {
@@ -92,6 +116,9 @@ type Tpv struct {
 	Ifsc          string
 }
 
+// PaymentData carries what ProcessOrder needs for the payment method: a
+// Vpa for UPI collect, and for cards either the card details or a saved
+// card's Token and Cryptogram (see CardToken.PaymentData).
 type PaymentData struct {
 	Vpa         string
 	CardNumber  string
@@ -99,8 +126,13 @@ type PaymentData struct {
 	ExpiryYear  string
 	Cvv         string
 	HolderName  string
+	Token       string
+	Cryptogram  string
 }
 
+// SetCustomHeaders adds headers to send with every request. Headers the SDK
+// sets itself, such as Authorization, take precedence. For headers that
+// depend on the request, see Use.
 func (c *Api) SetCustomHeaders(headers map[string]string) *Api {
 
 	if c.CustomHeaders == nil {
@@ -111,10 +143,6 @@ func (c *Api) SetCustomHeaders(headers map[string]string) *Api {
 		c.CustomHeaders[key] = value
 	}
 
-	for key, value := range c.CustomHeaders {
-		c.http.SetHeader(key, value)
-	}
-
 	return c
 
 }
@@ -134,29 +162,54 @@ func (c *Api) MakeAuthHeader() map[string]string {
 }
 
 func (c *Api) MakeHash(params map[string]interface{}) map[string]interface{} {
+	params["hash"] = c.ComputeHash(params)
+	return params
+}
+
+// ComputeHash returns the hash MakeHash would add to params without
+// modifying params. The string values are joined with "|" in key order,
+// followed by ApiSecret, and hashed with SHA-512. Any "hash" key is
+// ignored, as are values that are not strings.
+func (c *Api) ComputeHash(params map[string]interface{}) string {
 
-	valueString := ""
+	var valueString strings.Builder
 
 	keys := make([]string, 0, len(params))
 
 	for key := range params {
+		if key == "hash" {
+			continue
+		}
 		keys = append(keys, key)
 	}
 
 	sort.Strings(keys)
 
 	for _, key := range keys {
-		if _, ok := params[key].(string); !ok {
-			continue
+		if s, ok := params[key].(string); ok {
+			valueString.WriteString(s)
+			valueString.WriteString("|")
 		}
-		valueString += string(params[key].(string)) + "|"
 	}
 
-	valueString += c.ApiSecret
+	valueString.WriteString(c.ApiSecret)
 
-	hash := sha512.Sum512([]byte(valueString))
-	params["hash"] = fmt.Sprintf("%x", hash)
-	return params
+	hash := sha512.Sum512([]byte(valueString.String()))
+	return fmt.Sprintf("%x", hash)
+}
+
+// VerifyHash reports whether params["hash"] is the hash of the remaining
+// params, as signed by Paytring. The comparison runs in constant time and
+// params is not modified.
+func (c *Api) VerifyHash(params map[string]interface{}) (bool, error) {
+	received, ok := params["hash"].(string)
+	if !ok || received == "" {
+		return false, fmt.Errorf("hash is missing from params")
+	}
+
+	expected := c.ComputeHash(params)
+
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(received)), []byte(expected)) == 1, nil
 }
 
 func addToMapIfNotBlank(m map[string]interface{}, key string, value interface{}) {
@@ -172,64 +225,46 @@ func addToMapIfNotBlank(m map[string]interface{}, key string, value interface{})
 	}
 }
 
-func (c *Api) HandleResponse(response map[string]interface{}) (map[string]interface{}, error) {
-	if response["status"] == true {
-		return response, nil
-	}
-	message := extractErrorMessage(response["error"])
-	return nil, fmt.Errorf("%s", message)
+// addressMap renders the fields of address that are set. ShippingAddress
+// converts to BillingAddress, the two share their fields.
+func addressMap(address BillingAddress) map[string]interface{} {
+	m := make(map[string]interface{})
+	addToMapIfNotBlank(m, "firstname", address.Firstname)
+	addToMapIfNotBlank(m, "lastname", address.Lastname)
+	addToMapIfNotBlank(m, "phone", address.Phone)
+	addToMapIfNotBlank(m, "line1", address.Line1)
+	addToMapIfNotBlank(m, "line2", address.Line2)
+	addToMapIfNotBlank(m, "city", address.City)
+	addToMapIfNotBlank(m, "state", address.State)
+	addToMapIfNotBlank(m, "country", address.Country)
+	addToMapIfNotBlank(m, "zipcode", address.Zipcode)
+	return m
 }
 
-func extractErrorMessage(errors interface{}) string {
-	if errors == nil {
-		return "Something went wrong, invalid response received"
-	}
-	errorJSON, err := json.Marshal(errors)
-	if err != nil {
-		return fmt.Sprintf("Unexpected error response recieved: %v", err)
-	}
-	var errorMap map[string]interface{}
-	if err := json.Unmarshal(errorJSON, &errorMap); err != nil {
-		return fmt.Sprintf("Invalid error response recieved: %v", err)
-	}
-
-	message := errorMap["message"]
-
-	if message == nil {
-		return "Something went wrong, invalid data"
-	}
-
-	var messageMap map[string]interface{}
-	if err := json.Unmarshal([]byte(message.(string)), &messageMap); err != nil {
-		if s, ok := message.(string); ok {
-			return s
-		}
-		return fmt.Sprintf("Error unmarshalling message: %v", err)
-	}
-
-	for _, v := range messageMap {
-		if arr, ok := v.([]interface{}); ok && len(arr) > 0 {
-			if s, ok := arr[0].(string); ok {
-				return s
-			}
-		}
+// notesMap renders the user defined fields of notes that are set.
+func notesMap(notes *Notes) map[string]interface{} {
+	m := make(map[string]interface{})
+	if notes == nil {
+		return m
 	}
+	addToMapIfNotBlank(m, "udf1", notes.Udf1)
+	addToMapIfNotBlank(m, "udf2", notes.Udf2)
+	addToMapIfNotBlank(m, "udf3", notes.Udf3)
+	addToMapIfNotBlank(m, "udf4", notes.Udf4)
+	addToMapIfNotBlank(m, "udf5", notes.Udf5)
+	return m
+}
 
-	return "Something went wrong, please try again later."
+// validCallbackURL reports whether rawURL is an absolute http or https URL
+// Paytring can redirect the customer to.
+func validCallbackURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
 
-func validatePaymentDataForCard(paymentData PaymentData) error {
-	if paymentData.CardNumber == "" {
-		return fmt.Errorf("card number is required")
-	}
-	if paymentData.ExpiryMonth == "" {
-		return fmt.Errorf("expiry month is required")
-	}
-	if paymentData.ExpiryYear == "" {
-		return fmt.Errorf("expiry year is required")
-	}
-	if paymentData.Cvv == "" {
-		return fmt.Errorf("CVV is required")
+func (c *Api) HandleResponse(response map[string]interface{}) (map[string]interface{}, error) {
+	if response["status"] == true {
+		return response, nil
 	}
-	return nil
+	return nil, parseAPIError(response["error"])
 }
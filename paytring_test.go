@@ -1,18 +1,27 @@
 package paytring
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/paytring/go-sdk/paytringtest"
 	"github.com/stretchr/testify/assert"
 )
 
-var apiKey = "your_key"
-var apiSecret = "your_secret"
+var apiKey = "test_key"
+var apiSecret = "test_secret"
+
+// newTestClient returns a client talking to a fresh in-memory Paytring.
+func newTestClient(t *testing.T) (*Api, *paytringtest.Server) {
+	server := paytringtest.NewServer(apiKey, apiSecret)
+	t.Cleanup(server.Close)
+	return NewClient(apiKey, apiSecret, WithBaseURL(server.URL)), server
+}
 
 func TestCreateOrder(t *testing.T) {
 
-	var amount int64 = 1000
+	amount := NewMoney(1000, "INR")
 	receiptID := "TEST123"
 	callbackURL := "https://example.com/callback"
 	customer := Customer{
@@ -24,7 +33,7 @@ func TestCreateOrder(t *testing.T) {
 		Currency: "INR",
 	}
 
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, _ := newTestClient(t)
 	resp, err := paytring.CreateOrder(amount, receiptID, callbackURL, customer, paymentConfig)
 	fmt.Println(resp)
 	assert.NoError(t, err)
@@ -33,9 +42,10 @@ func TestCreateOrder(t *testing.T) {
 
 func TestFetchOrder(t *testing.T) {
 
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "771606428862383868", Amount: 1000})
 	resp, err := paytring.FetchOrder("771606428862383868", "advance")
-	if resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != false {
 		assert.True(t, true)
 	}
 	assert.NoError(t, err)
@@ -63,11 +73,59 @@ func TestMakeHash(t *testing.T) {
 	assert.Equal(t, expectedHash, resp["hash"])
 }
 
+func TestComputeHash(t *testing.T) {
+
+	client := NewClient(apiKey, apiSecret)
+
+	params := map[string]interface{}{
+		"amount":       "100",
+		"callback_url": "https://example.com/callback",
+		"cname":        "JohnDoe",
+		"email":        "john.doe@example.com",
+		"phone":        "1234567890",
+		"key":          apiKey,
+		"receipt_id":   "TEST123",
+	}
+
+	hash := client.ComputeHash(params)
+	assert.NotContains(t, params, "hash")
+	assert.Len(t, hash, 128)
+
+	assert.Equal(t, hash, client.MakeHash(MergeMaps(params, nil))["hash"])
+
+	params["hash"] = "ignored"
+	assert.Equal(t, hash, client.ComputeHash(params))
+}
+
+func TestVerifyHash(t *testing.T) {
+
+	client := NewClient(apiKey, apiSecret)
+
+	params := client.MakeHash(map[string]interface{}{
+		"order_id": "771606428862383868",
+		"status":   "success",
+	})
+
+	ok, err := client.VerifyHash(params)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	params["status"] = "failed"
+	ok, err = client.VerifyHash(params)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	delete(params, "hash")
+	_, err = client.VerifyHash(params)
+	assert.Error(t, err)
+}
+
 func TestFetchOrderByReceipt(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{ReceiptId: "TEST_RECEIPT_ID_123", Amount: 1000})
 	resp, err := paytring.FetchOrderByReceipt("TEST_RECEIPT_ID_123")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -75,10 +133,10 @@ func TestFetchOrderByReceipt(t *testing.T) {
 }
 
 func TestValidateVPA(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, _ := newTestClient(t)
 	resp, err := paytring.ValidateVPA("test@vpa")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -86,10 +144,10 @@ func TestValidateVPA(t *testing.T) {
 }
 
 func TestValidateCard(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, _ := newTestClient(t)
 	resp, err := paytring.ValidateCard("418730")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -97,11 +155,12 @@ func TestValidateCard(t *testing.T) {
 }
 
 func TestProcessOrder(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_PROCESS", Amount: 1000})
 	// Example for a UPI payment, adjust as needed
 	resp, err := paytring.ProcessOrder("TEST_ORDER_ID_PROCESS", "upi", "collect", PaymentData{Vpa: "test@upi"}, "desktop")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -109,10 +168,11 @@ func TestProcessOrder(t *testing.T) {
 }
 
 func TestRefundOrder(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_REFUND", Amount: 1000, Status: paytringtest.StatusSuccess})
 	resp, err := paytring.RefundOrder("TEST_ORDER_ID_REFUND")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -120,10 +180,11 @@ func TestRefundOrder(t *testing.T) {
 }
 
 func TestFetchRefundStatus(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddRefund(paytringtest.Refund{RefundId: "TEST_REFUND_ID_STATUS", Amount: 1000})
 	resp, err := paytring.FetchRefundStatus("TEST_REFUND_ID_STATUS")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -131,22 +192,38 @@ func TestFetchRefundStatus(t *testing.T) {
 }
 
 func TestPartialRefund(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
-	var amount int64 = 100
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_PARTIAL_REFUND", Amount: 1000, Status: paytringtest.StatusSuccess})
+	amount := NewMoney(100, "INR")
 	resp, err := paytring.PartialRefund("TEST_ORDER_ID_PARTIAL_REFUND", amount)
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
 	assert.NotNil(t, resp, "Response should not be nil on success")
 }
 
+func TestPartialRefundSendsCurrency(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_PARTIAL_REFUND_USD", Amount: 1000, Status: paytringtest.StatusSuccess})
+
+	_, err := client.PartialRefund("TEST_ORDER_ID_PARTIAL_REFUND_USD", NewMoney(100, "usd"))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "The currency must match the order currency.", apiErr.FieldError("currency"))
+
+	_, err = client.PartialRefund("TEST_ORDER_ID_PARTIAL_REFUND_USD", NewMoney(100, "INR"))
+	assert.NoError(t, err)
+}
+
 func TestFetchRefundAttempts(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_REFUND_ATTEMPTS", Amount: 1000, Status: paytringtest.StatusPartiallyRefunded, Refunded: 100})
+	server.AddRefund(paytringtest.Refund{OrderId: "TEST_ORDER_ID_REFUND_ATTEMPTS", Amount: 100})
 	resp, err := paytring.FetchRefundAttempts("TEST_ORDER_ID_REFUND_ATTEMPTS")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -154,10 +231,11 @@ func TestFetchRefundAttempts(t *testing.T) {
 }
 
 func TestFetchRefund(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddRefund(paytringtest.Refund{RefundId: "TEST_REFUND_ID_FETCH", Amount: 1000})
 	resp, err := paytring.FetchRefund("TEST_REFUND_ID_FETCH")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -165,29 +243,31 @@ func TestFetchRefund(t *testing.T) {
 }
 func TestCurrencyConversion(t *testing.T) {
 
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, _ := newTestClient(t)
 	resp, err := paytring.CurrencyConversion("USD", "INR")
-	if resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != false {
 		assert.True(t, true)
 	}
 	assert.NoError(t, err)
 
 }
 func TestCaptureOrder(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "772677896533967081", Amount: 1000, Status: paytringtest.StatusAuthorized})
 	resp, err := paytring.CaptureOrder("772677896533967081")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
 	assert.NotNil(t, resp, "Response should not be nil on success")
 }
 func TestCancelOrder(t *testing.T) {
-	paytring := NewClient(apiKey, apiSecret)
+	paytring, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "772666991739703525", Amount: 1000})
 	resp, err := paytring.CancelOrder("772666991739703525")
 	fmt.Println(resp)
-	if resp != nil && resp["status"] != nil && resp["status"] != false {
+	if resp != nil && resp.Raw["status"] != nil && resp.Raw["status"] != false {
 		assert.True(t, true, "Expected status to be non-false if present")
 	}
 	assert.NoError(t, err)
@@ -0,0 +1,146 @@
+// Package paytringotel reports Paytring API calls to OpenTelemetry. It lives
+// in its own module so that the SDK itself does not depend on OpenTelemetry.
+//
+//	inst, err := paytringotel.New()
+//	if err != nil {
+//		// handle error
+//	}
+//	client := paytring.NewClient(apiKey, apiSecret, paytring.WithInstrumentation(inst))
+//
+// Every call becomes a client span named after the SDK method, and is
+// counted in the paytring.client.requests, paytring.client.failures and
+// paytring.client.retries counters and the paytring.client.duration
+// histogram.
+package paytringotel
+
+import (
+	"context"
+	"fmt"
+
+	paytring "github.com/paytring/go-sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/paytring/go-sdk/paytringotel"
+
+// Attribute keys set on spans and, except for the order id, on metrics.
+const (
+	MethodKey     = attribute.Key("paytring.method")
+	EndpointKey   = attribute.Key("paytring.endpoint")
+	OrderIdKey    = attribute.Key("paytring.order_id")
+	AttemptsKey   = attribute.Key("paytring.attempts")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+)
+
+// Instrumentation implements paytring.Instrumentation with OpenTelemetry.
+type Instrumentation struct {
+	tracer   trace.Tracer
+	requests metric.Int64Counter
+	failures metric.Int64Counter
+	retries  metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// Option configures an Instrumentation created by New.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider replaces the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider replaces the global meter provider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// New creates an Instrumentation using the global providers unless
+// options say otherwise.
+func New(opts ...Option) (*Instrumentation, error) {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	meter := c.meterProvider.Meter(ScopeName)
+	inst := &Instrumentation{tracer: c.tracerProvider.Tracer(ScopeName)}
+
+	var err error
+	if inst.requests, err = meter.Int64Counter("paytring.client.requests",
+		metric.WithDescription("Paytring API calls made.")); err != nil {
+		return nil, fmt.Errorf("failed to create requests counter: %w", err)
+	}
+	if inst.failures, err = meter.Int64Counter("paytring.client.failures",
+		metric.WithDescription("Paytring API calls that returned an error.")); err != nil {
+		return nil, fmt.Errorf("failed to create failures counter: %w", err)
+	}
+	if inst.retries, err = meter.Int64Counter("paytring.client.retries",
+		metric.WithDescription("Requests sent again after a failed attempt.")); err != nil {
+		return nil, fmt.Errorf("failed to create retries counter: %w", err)
+	}
+	if inst.duration, err = meter.Float64Histogram("paytring.client.duration",
+		metric.WithDescription("Duration of Paytring API calls, retries included."),
+		metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("failed to create duration histogram: %w", err)
+	}
+
+	return inst, nil
+}
+
+// StartCall starts a client span for call and records its metrics once
+// the returned func is called.
+func (i *Instrumentation) StartCall(ctx context.Context, call paytring.CallInfo) (context.Context, func(paytring.CallResult)) {
+	attrs := []attribute.KeyValue{MethodKey.String(call.Method), EndpointKey.String(call.Endpoint)}
+
+	ctx, span := i.tracer.Start(ctx, "paytring."+call.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+
+	return ctx, func(result paytring.CallResult) {
+		if result.OrderId != "" {
+			span.SetAttributes(OrderIdKey.String(result.OrderId))
+		}
+		span.SetAttributes(AttemptsKey.Int(result.Attempts))
+
+		// Order ids would give every call its own series, so metrics
+		// only carry the method, endpoint and status.
+		metricAttrs := attrs
+		if result.StatusCode != 0 {
+			status := StatusCodeKey.Int(result.StatusCode)
+			span.SetAttributes(status)
+			metricAttrs = append(metricAttrs[:len(metricAttrs):len(metricAttrs)], status)
+		}
+		set := metric.WithAttributes(metricAttrs...)
+
+		i.requests.Add(ctx, 1, set)
+		i.duration.Record(ctx, result.Duration.Seconds(), set)
+		if result.Attempts > 1 {
+			i.retries.Add(ctx, int64(result.Attempts-1), set)
+		}
+		if result.Err != nil {
+			i.failures.Add(ctx, 1, set)
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		}
+		span.End()
+	}
+}
+
+var _ paytring.Instrumentation = (*Instrumentation)(nil)
@@ -0,0 +1,85 @@
+package paytringotel
+
+import (
+	"context"
+	"testing"
+
+	paytring "github.com/paytring/go-sdk"
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrumentation(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	inst, err := New(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	assert.NoError(t, err)
+
+	server := paytringtest.NewServer("test_key", "test_secret")
+	defer server.Close()
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID", Amount: 1000})
+	server.FailNext("v2/order/fetch", paytringtest.Failure{Status: 503})
+
+	client := paytring.NewClient("test_key", "test_secret",
+		paytring.WithBaseURL(server.URL),
+		paytring.WithInstrumentation(inst),
+		paytring.WithRetryPolicy(paytring.RetryPolicy{MaxAttempts: 2, RetryableStatusCodes: []int{503}}))
+
+	_, err = client.FetchOrder("TEST_ORDER_ID", "normal")
+	assert.NoError(t, err)
+	_, err = client.CancelOrder("MISSING")
+	assert.Error(t, err)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 2)
+
+	fetch := ended[0]
+	assert.Equal(t, "paytring.FetchOrder", fetch.Name())
+	assert.Equal(t, trace.SpanKindClient, fetch.SpanKind())
+	assert.Contains(t, fetch.Attributes(), EndpointKey.String("v2/order/fetch"))
+	assert.Contains(t, fetch.Attributes(), OrderIdKey.String("TEST_ORDER_ID"))
+	assert.Contains(t, fetch.Attributes(), StatusCodeKey.Int(200))
+	assert.Contains(t, fetch.Attributes(), AttemptsKey.Int(2))
+
+	cancel := ended[1]
+	assert.Equal(t, codes.Error, cancel.Status().Code)
+	assert.Contains(t, cancel.Attributes(), StatusCodeKey.Int(404))
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	sums := map[string]int64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if data, ok := m.Data.(metricdata.Sum[int64]); ok {
+			for _, point := range data.DataPoints {
+				sums[m.Name] += point.Value
+				_, hasOrder := point.Attributes.Value(OrderIdKey)
+				assert.False(t, hasOrder, m.Name)
+			}
+		}
+		if data, ok := m.Data.(metricdata.Histogram[float64]); ok {
+			var sets []attribute.Set
+			for _, point := range data.DataPoints {
+				sets = append(sets, point.Attributes)
+			}
+			assert.Len(t, sets, 2)
+			assert.Contains(t, sets, attribute.NewSet(MethodKey.String("FetchOrder"), EndpointKey.String("v2/order/fetch"), StatusCodeKey.Int(200)))
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"paytring.client.requests": 2,
+		"paytring.client.failures": 1,
+		"paytring.client.retries":  1,
+	}, sums)
+}
@@ -0,0 +1,174 @@
+package paytringtest
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Customer returns a copy of the stored customer.
+func (s *Server) Customer(customerId string) (Customer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.customers[customerId]
+	if !ok {
+		return Customer{}, false
+	}
+	return *c, true
+}
+
+func createCustomer(s *Server, params map[string]interface{}) (int, interface{}) {
+	invalid := map[string]string{}
+	if str(params, "cname") == "" {
+		invalid["cname"] = "The name field is required."
+	}
+	if str(params, "email") == "" && str(params, "phone") == "" {
+		invalid["phone"] = "The phone field is required when email is not present."
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	c := &Customer{
+		CustomerId:  "cust_" + s.nextID(),
+		Name:        str(params, "cname"),
+		Email:       str(params, "email"),
+		Phone:       str(params, "phone"),
+		Instruments: map[string]map[string]interface{}{},
+		CreatedAt:   time.Now(),
+	}
+	if notes, isMap := params["notes"].(map[string]interface{}); isMap {
+		c.Notes = notes
+	}
+	s.customers[c.CustomerId] = c
+
+	return success(map[string]interface{}{"customer": customerJSON(c)})
+}
+
+func updateCustomer(s *Server, params map[string]interface{}) (int, interface{}) {
+	c, found := s.customers[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Customer not found")
+	}
+	if v := str(params, "cname"); v != "" {
+		c.Name = v
+	}
+	if v := str(params, "email"); v != "" {
+		c.Email = v
+	}
+	if v := str(params, "phone"); v != "" {
+		c.Phone = v
+	}
+	if notes, isMap := params["notes"].(map[string]interface{}); isMap {
+		c.Notes = notes
+	}
+	return success(map[string]interface{}{"customer": customerJSON(c)})
+}
+
+func fetchCustomer(s *Server, params map[string]interface{}) (int, interface{}) {
+	c, found := s.customers[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Customer not found")
+	}
+	return success(map[string]interface{}{"customer": customerJSON(c)})
+}
+
+func addCustomerAddress(s *Server, params map[string]interface{}) (int, interface{}) {
+	c, found := s.customers[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Customer not found")
+	}
+	address, isMap := params["address"].(map[string]interface{})
+	if !isMap || str(address, "line1") == "" {
+		return fieldErrors(map[string]string{"address.line1": "The address line1 field is required."})
+	}
+
+	switch str(params, "type") {
+	case "billing":
+		c.BillingAddresses = append(c.BillingAddresses, address)
+	case "shipping":
+		c.ShippingAddresses = append(c.ShippingAddresses, address)
+	default:
+		return fieldErrors(map[string]string{"type": "The selected type is invalid."})
+	}
+	return success(map[string]interface{}{"customer": customerJSON(c)})
+}
+
+func attachInstrument(s *Server, params map[string]interface{}) (int, interface{}) {
+	c, found := s.customers[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Customer not found")
+	}
+
+	instrument := map[string]interface{}{"type": str(params, "type")}
+	switch str(params, "type") {
+	case "card":
+		t, found := s.tokens[str(params, "token_id")]
+		if !found || t.Status == TokenDeleted || t.CustomerId != c.CustomerId {
+			return fieldErrors(map[string]string{"token_id": "The selected token id is invalid."})
+		}
+		instrument["token_id"] = t.TokenId
+		instrument["brand"] = t.Brand
+		instrument["last4"] = t.Last4
+	case "upi":
+		if !vpaPattern.MatchString(str(params, "vpa")) {
+			return fieldErrors(map[string]string{"vpa": "The vpa is invalid."})
+		}
+		instrument["vpa"] = str(params, "vpa")
+	default:
+		return fieldErrors(map[string]string{"type": "The selected type is invalid."})
+	}
+
+	instrumentId := "inst_" + s.nextID()
+	instrument["instrument_id"] = instrumentId
+	c.Instruments[instrumentId] = instrument
+
+	return success(map[string]interface{}{"customer": customerJSON(c)})
+}
+
+func detachInstrument(s *Server, params map[string]interface{}) (int, interface{}) {
+	c, found := s.customers[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Customer not found")
+	}
+	instrumentId := str(params, "instrument_id")
+	if _, found := c.Instruments[instrumentId]; !found {
+		return http.StatusNotFound, errorBody("Instrument not found")
+	}
+	delete(c.Instruments, instrumentId)
+	return success(map[string]interface{}{"customer": customerJSON(c)})
+}
+
+func customerJSON(c *Customer) map[string]interface{} {
+	ids := make([]string, 0, len(c.Instruments))
+	for id := range c.Instruments {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	instruments := []interface{}{}
+	for _, id := range ids {
+		instruments = append(instruments, c.Instruments[id])
+	}
+
+	billing := []interface{}{}
+	for _, address := range c.BillingAddresses {
+		billing = append(billing, address)
+	}
+	shipping := []interface{}{}
+	for _, address := range c.ShippingAddresses {
+		shipping = append(shipping, address)
+	}
+
+	return map[string]interface{}{
+		"customer_id":        c.CustomerId,
+		"name":               c.Name,
+		"email":              c.Email,
+		"phone":              c.Phone,
+		"notes":              c.Notes,
+		"billing_addresses":  billing,
+		"shipping_addresses": shipping,
+		"instruments":        instruments,
+		"created_at":         c.CreatedAt.Format(time.RFC3339),
+	}
+}
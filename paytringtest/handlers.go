@@ -0,0 +1,376 @@
+package paytringtest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var vpaPattern = regexp.MustCompile(`^[\w.\-]{2,256}@[a-zA-Z]{2,64}$`)
+
+func createOrder(s *Server, params map[string]interface{}) (int, interface{}) {
+	receiptId := str(params, "receipt_id")
+	amount, err := strconv.ParseInt(str(params, "amount"), 10, 64)
+
+	invalid := map[string]string{}
+	if receiptId == "" {
+		invalid["receipt_id"] = "The receipt id field is required."
+	} else if _, exists := s.receipts[receiptId]; exists {
+		invalid["receipt_id"] = "The receipt id has already been taken."
+	}
+	if err != nil || amount <= 0 {
+		invalid["amount"] = "The amount must be greater than 0."
+	}
+	if str(params, "callback_url") == "" {
+		invalid["callback_url"] = "The callback url field is required."
+	}
+	if str(params, "pg") != "" && str(params, "pg_pool_id") != "" {
+		invalid["pg_pool_id"] = "The pg pool id cannot be used together with pg."
+	}
+	customer := map[string]string{
+		"name":  str(params, "cname"),
+		"email": str(params, "email"),
+		"phone": str(params, "phone"),
+	}
+	if customerId := str(params, "customer_id"); customerId != "" {
+		if c, found := s.customers[customerId]; !found {
+			invalid["customer_id"] = "The selected customer id is invalid."
+		} else {
+			saved := map[string]string{"name": c.Name, "email": c.Email, "phone": c.Phone}
+			for field, v := range customer {
+				if v == "" {
+					customer[field] = saved[field]
+				}
+			}
+			customer["customer_id"] = customerId
+		}
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	o := &Order{
+		OrderId:     s.nextID(),
+		ReceiptId:   receiptId,
+		Amount:      amount,
+		Currency:    str(params, "currency"),
+		Status:      StatusCreated,
+		Pg:          str(params, "pg"),
+		PgPoolId:    str(params, "pg_pool_id"),
+		AutoCapture: str(params, "auto_capture") == "true",
+		Customer:    customer,
+	}
+	if notes, isMap := params["notes"].(map[string]interface{}); isMap {
+		o.Notes = notes
+	}
+	if o.Pg == "" {
+		o.Pg = s.DefaultPg
+	}
+	s.orders[o.OrderId] = o
+	s.receipts[receiptId] = o.OrderId
+
+	return success(map[string]interface{}{
+		"order_id":   o.OrderId,
+		"url":        strings.TrimSuffix(s.URL, "api/") + "pay/" + o.OrderId,
+		"pg":         o.Pg,
+		"pg_pool_id": o.PgPoolId,
+	})
+}
+
+func fetchOrder(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	return success(map[string]interface{}{"order": orderJSON(o)})
+}
+
+func fetchOrderByReceipt(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[s.receipts[str(params, "id")]]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	return success(map[string]interface{}{"order": orderJSON(o)})
+}
+
+func processOrder(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[str(params, "order_id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	if o.Status != StatusCreated && o.Status != StatusFailed {
+		return http.StatusUnprocessableEntity, errorBody("Order cannot be processed in status " + o.Status)
+	}
+
+	method := str(params, "method")
+	code := str(params, "code")
+	switch method {
+	case "":
+		return fieldErrors(map[string]string{"method": "The method field is required."})
+	case "upi":
+		if code == "collect" && !vpaPattern.MatchString(str(params, "vpa")) {
+			return fieldErrors(map[string]string{"vpa": "The vpa is invalid."})
+		}
+	case "card", "emi":
+		if status, body, failed := s.checkCard(params); failed {
+			return status, body
+		}
+		if method == "emi" {
+			emi, _ := params["emi"].(map[string]interface{})
+			if tenure, err := strconv.Atoi(str(emi, "tenure")); err != nil || tenure <= 0 {
+				return fieldErrors(map[string]string{"emi.tenure": "The emi tenure field is required."})
+			}
+		}
+	case "netbanking", "wallet":
+		if code == "" {
+			return fieldErrors(map[string]string{"code": "The code field is required."})
+		}
+	case "cardless_emi", "bnpl":
+		invalid := map[string]string{}
+		if code == "" {
+			invalid["code"] = "The code field is required."
+		}
+		if str(params, "phone") == "" {
+			invalid["phone"] = "The phone field is required."
+		}
+		if len(invalid) > 0 {
+			return fieldErrors(invalid)
+		}
+	}
+
+	o.Method = method
+	o.Code = code
+	o.Status = StatusPending
+
+	response := map[string]interface{}{
+		"order_id": o.OrderId,
+		"method":   o.Method,
+		"pg":       o.Pg,
+	}
+	switch {
+	case method == "upi" && code == "intent":
+		response["intent_url"] = s.upiURI(o)
+	case method == "upi" && code == "qr":
+		response["qr_string"] = base64.StdEncoding.EncodeToString([]byte(s.upiURI(o)))
+	default:
+		response["url"] = strings.TrimSuffix(s.URL, "api/") + "pay/" + o.OrderId + "/authenticate"
+	}
+	return success(response)
+}
+
+// checkCard validates the card of a process order request. failed is true
+// when the request must be rejected with status and body.
+func (s *Server) checkCard(params map[string]interface{}) (status int, body interface{}, failed bool) {
+	card, isMap := params["card"].(map[string]interface{})
+	if !isMap {
+		status, body = fieldErrors(map[string]string{"card": "The card field is required."})
+		return status, body, true
+	}
+	if tokenId := str(card, "token"); tokenId != "" {
+		if t, found := s.tokens[tokenId]; !found || t.Status != TokenActive {
+			status, body = fieldErrors(map[string]string{"card.token": "The selected card token is invalid."})
+			return status, body, true
+		}
+		if str(card, "cryptogram") == "" {
+			status, body = fieldErrors(map[string]string{"card.cryptogram": "The cryptogram field is required with a token."})
+			return status, body, true
+		}
+	}
+	return 0, nil, false
+}
+
+// upiURI is the deep link that pays o, as found in UPI intents and QR
+// codes.
+func (s *Server) upiURI(o *Order) string {
+	query := url.Values{}
+	query.Set("pa", "paytring@okaxis")
+	query.Set("pn", "Paytring")
+	query.Set("tr", o.OrderId)
+	query.Set("am", fmt.Sprintf("%d.%02d", o.Amount/100, o.Amount%100))
+	if o.Currency != "" {
+		query.Set("cu", o.Currency)
+	}
+	return "upi://pay?" + query.Encode()
+}
+
+func cancelOrder(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	switch o.Status {
+	case StatusCreated, StatusPending, StatusAuthorized:
+		o.Status = StatusCancelled
+	default:
+		return http.StatusUnprocessableEntity, errorBody("Order cannot be cancelled in status " + o.Status)
+	}
+	return success(map[string]interface{}{"order": orderJSON(o)})
+}
+
+func captureOrder(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	if o.Status != StatusAuthorized {
+		return http.StatusUnprocessableEntity, errorBody("Order cannot be captured in status " + o.Status)
+	}
+	o.Status = StatusSuccess
+	return success(map[string]interface{}{"order": orderJSON(o)})
+}
+
+func refundOrder(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	return s.refund(o, o.Amount-o.Refunded)
+}
+
+func partialRefund(s *Server, params map[string]interface{}) (int, interface{}) {
+	o, found := s.orders[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+	amount, err := strconv.ParseInt(str(params, "amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		return fieldErrors(map[string]string{"amount": "The amount must be greater than 0."})
+	}
+	if currency := str(params, "currency"); currency != "" && currency != o.Currency {
+		return fieldErrors(map[string]string{"currency": "The currency must match the order currency."})
+	}
+	return s.refund(o, amount)
+}
+
+func (s *Server) refund(o *Order, amount int64) (int, interface{}) {
+	if o.Status != StatusSuccess && o.Status != StatusPartiallyRefunded {
+		return http.StatusUnprocessableEntity, errorBody("Order cannot be refunded in status " + o.Status)
+	}
+	if amount <= 0 || amount > o.Amount-o.Refunded {
+		return fieldErrors(map[string]string{"amount": "The amount exceeds the refundable amount."})
+	}
+
+	r := &Refund{
+		RefundId:  s.nextID(),
+		OrderId:   o.OrderId,
+		Amount:    amount,
+		Currency:  o.Currency,
+		Status:    "processed",
+		CreatedAt: time.Now(),
+	}
+	s.refunds[r.RefundId] = r
+
+	o.Refunded += amount
+	if o.Refunded == o.Amount {
+		o.Status = StatusRefunded
+	} else {
+		o.Status = StatusPartiallyRefunded
+	}
+
+	return success(map[string]interface{}{"refund": refundJSON(r)})
+}
+
+func fetchRefund(s *Server, params map[string]interface{}) (int, interface{}) {
+	r, found := s.refunds[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Refund not found")
+	}
+	return success(map[string]interface{}{"refund": refundJSON(r)})
+}
+
+func refundAttempts(s *Server, params map[string]interface{}) (int, interface{}) {
+	orderId := str(params, "order_id")
+	if _, found := s.orders[orderId]; !found {
+		return http.StatusNotFound, errorBody("Order not found")
+	}
+
+	attempts := []interface{}{}
+	for _, r := range s.refunds {
+		if r.OrderId == orderId {
+			attempts = append(attempts, refundJSON(r))
+		}
+	}
+	return success(map[string]interface{}{"refunds": attempts})
+}
+
+func validateVPA(s *Server, params map[string]interface{}) (int, interface{}) {
+	vpa := str(params, "vpa")
+	valid := vpaPattern.MatchString(vpa)
+	name := ""
+	if valid {
+		name = strings.ToUpper(strings.SplitN(vpa, "@", 2)[0])
+	}
+	return success(map[string]interface{}{"vpa": vpa, "valid": valid, "name": name})
+}
+
+func validateBin(s *Server, params map[string]interface{}) (int, interface{}) {
+	bin := str(params, "bin_code")
+	if len(bin) < 6 {
+		return fieldErrors(map[string]string{"bin_code": "The bin code must be at least 6 digits."})
+	}
+
+	network := "unknown"
+	switch {
+	case strings.HasPrefix(bin, "4"):
+		network = "visa"
+	case strings.HasPrefix(bin, "5"), strings.HasPrefix(bin, "2"):
+		network = "mastercard"
+	case strings.HasPrefix(bin, "34"), strings.HasPrefix(bin, "37"):
+		network = "amex"
+	case strings.HasPrefix(bin, "6"):
+		network = "rupay"
+	}
+
+	return success(map[string]interface{}{
+		"bin":     bin[:6],
+		"network": network,
+		"type":    "credit",
+		"issuer":  "TEST BANK",
+		"country": "IN",
+	})
+}
+
+func currencyConversion(s *Server, params map[string]interface{}) (int, interface{}) {
+	from, to := str(params, "from"), str(params, "to")
+	rate, found := s.rates[from+":"+to]
+	if from == to {
+		rate, found = "1", true
+	}
+	if !found {
+		return http.StatusNotFound, errorBody("Currency pair not supported")
+	}
+	return success(map[string]interface{}{"from": from, "to": to, "rate": rate})
+}
+
+func orderJSON(o *Order) map[string]interface{} {
+	return map[string]interface{}{
+		"order_id":        o.OrderId,
+		"receipt_id":      o.ReceiptId,
+		"amount":          strconv.FormatInt(o.Amount, 10),
+		"refunded_amount": strconv.FormatInt(o.Refunded, 10),
+		"currency":        o.Currency,
+		"order_status":    o.Status,
+		"pg":              o.Pg,
+		"pg_pool_id":      o.PgPoolId,
+		"method":          o.Method,
+		"code":            o.Code,
+		"customer":        o.Customer,
+		"notes":           o.Notes,
+	}
+}
+
+func refundJSON(r *Refund) map[string]interface{} {
+	return map[string]interface{}{
+		"refund_id":     r.RefundId,
+		"order_id":      r.OrderId,
+		"amount":        strconv.FormatInt(r.Amount, 10),
+		"currency":      r.Currency,
+		"refund_status": r.Status,
+		"created_at":    r.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,241 @@
+package paytringtest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payment link statuses used by the fake.
+const (
+	LinkCreated   = "created"
+	LinkPaid      = "paid"
+	LinkExpired   = "expired"
+	LinkCancelled = "cancelled"
+)
+
+// AddLink stores l as if it had been created. LinkId is generated when
+// empty and Status defaults to LinkCreated.
+func (s *Server) AddLink(l Link) Link {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l.LinkId == "" {
+		l.LinkId = s.nextID()
+	}
+	if l.Status == "" {
+		l.Status = LinkCreated
+	}
+	if l.Currency == "" {
+		l.Currency = "INR"
+	}
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = time.Now()
+	}
+	s.links[l.LinkId] = &l
+	return l
+}
+
+// Link returns a copy of the stored link.
+func (s *Server) Link(linkId string) (Link, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.links[linkId]
+	if !ok {
+		return Link{}, false
+	}
+	s.expire(l)
+	return *l, true
+}
+
+// PayLink pays an open link in full, as a customer following it would. It
+// returns the order created for the payment.
+func (s *Server) PayLink(linkId string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.links[linkId]
+	if !ok {
+		return Order{}, false
+	}
+	s.expire(l)
+	if l.Status != LinkCreated {
+		return Order{}, false
+	}
+
+	o := &Order{
+		OrderId:     s.nextID(),
+		ReceiptId:   l.ReceiptId,
+		Amount:      l.Amount,
+		Currency:    l.Currency,
+		Status:      StatusSuccess,
+		Pg:          s.DefaultPg,
+		AutoCapture: true,
+		Customer:    l.Customer,
+		Notes:       l.Notes,
+	}
+	s.orders[o.OrderId] = o
+	l.OrderIds = append(l.OrderIds, o.OrderId)
+	l.Status = LinkPaid
+	return *o, true
+}
+
+// expire moves an open link past its expiry to LinkExpired.
+func (s *Server) expire(l *Link) {
+	if l.Status == LinkCreated && !l.ExpireAt.IsZero() && time.Now().After(l.ExpireAt) {
+		l.Status = LinkExpired
+	}
+}
+
+func createLink(s *Server, params map[string]interface{}) (int, interface{}) {
+	amount, err := strconv.ParseInt(str(params, "amount"), 10, 64)
+
+	invalid := map[string]string{}
+	if str(params, "receipt_id") == "" {
+		invalid["receipt_id"] = "The receipt id field is required."
+	}
+	if err != nil || amount <= 0 {
+		invalid["amount"] = "The amount must be greater than 0."
+	}
+
+	var expireAt time.Time
+	if v := str(params, "expire_at"); v != "" {
+		expireAt, err = time.Parse(time.RFC3339, v)
+		if err != nil || !expireAt.After(time.Now()) {
+			invalid["expire_at"] = "The expire at must be a date in the future."
+		}
+	}
+
+	var notify []string
+	if channels, isList := params["notify"].([]interface{}); isList {
+		for _, channel := range channels {
+			notify = append(notify, channel.(string))
+		}
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	l := &Link{
+		LinkId:      s.nextID(),
+		ReceiptId:   str(params, "receipt_id"),
+		Amount:      amount,
+		Currency:    str(params, "currency"),
+		Status:      LinkCreated,
+		Description: str(params, "description"),
+		Customer: map[string]string{
+			"name":  str(params, "cname"),
+			"email": str(params, "email"),
+			"phone": str(params, "phone"),
+		},
+		ExpireAt:  expireAt,
+		Notify:    notify,
+		CreatedAt: time.Now(),
+	}
+	if notes, isMap := params["notes"].(map[string]interface{}); isMap {
+		l.Notes = notes
+	}
+	if len(notify) > 0 {
+		l.Sent++
+	}
+	s.links[l.LinkId] = l
+
+	return success(map[string]interface{}{"link": s.linkJSON(l)})
+}
+
+func fetchLink(s *Server, params map[string]interface{}) (int, interface{}) {
+	l, found := s.links[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Link not found")
+	}
+	s.expire(l)
+	return success(map[string]interface{}{"link": s.linkJSON(l)})
+}
+
+func listLinks(s *Server, params map[string]interface{}) (int, interface{}) {
+	var matched []*Link
+	for _, l := range s.links {
+		s.expire(l)
+		if status := str(params, "link_status"); status != "" && l.Status != status {
+			continue
+		}
+		if receiptId := str(params, "receipt_id"); receiptId != "" && l.ReceiptId != receiptId {
+			continue
+		}
+		matched = append(matched, l)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].LinkId < matched[j].LinkId })
+
+	links := []interface{}{}
+	for _, l := range matched {
+		links = append(links, s.linkJSON(l))
+	}
+	return success(map[string]interface{}{"links": links})
+}
+
+func cancelLink(s *Server, params map[string]interface{}) (int, interface{}) {
+	l, found := s.links[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Link not found")
+	}
+	s.expire(l)
+	if l.Status != LinkCreated {
+		return http.StatusUnprocessableEntity, errorBody("Link cannot be cancelled in status " + l.Status)
+	}
+	l.Status = LinkCancelled
+	return success(map[string]interface{}{"link": s.linkJSON(l)})
+}
+
+func resendLink(s *Server, params map[string]interface{}) (int, interface{}) {
+	l, found := s.links[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Link not found")
+	}
+	s.expire(l)
+	if l.Status != LinkCreated {
+		return http.StatusUnprocessableEntity, errorBody("Link cannot be sent in status " + l.Status)
+	}
+	if _, given := params["notify"]; !given && len(l.Notify) == 0 {
+		return fieldErrors(map[string]string{"notify": "The notify field is required."})
+	}
+	l.Sent++
+	return success(map[string]interface{}{"link": s.linkJSON(l)})
+}
+
+func (s *Server) linkJSON(l *Link) map[string]interface{} {
+	var paid int64
+	payments := []interface{}{}
+	for _, orderId := range l.OrderIds {
+		if o, ok := s.orders[orderId]; ok {
+			payments = append(payments, map[string]interface{}{
+				"order_id":     o.OrderId,
+				"amount":       strconv.FormatInt(o.Amount, 10),
+				"order_status": o.Status,
+			})
+			if o.Status == StatusSuccess {
+				paid += o.Amount
+			}
+		}
+	}
+
+	expireAt := ""
+	if !l.ExpireAt.IsZero() {
+		expireAt = l.ExpireAt.Format(time.RFC3339)
+	}
+
+	return map[string]interface{}{
+		"link_id":     l.LinkId,
+		"receipt_id":  l.ReceiptId,
+		"url":         strings.TrimSuffix(s.URL, "api/") + "link/" + l.LinkId,
+		"amount":      strconv.FormatInt(l.Amount, 10),
+		"amount_paid": strconv.FormatInt(paid, 10),
+		"currency":    l.Currency,
+		"link_status": l.Status,
+		"description": l.Description,
+		"customer":    l.Customer,
+		"notes":       l.Notes,
+		"expire_at":   expireAt,
+		"created_at":  l.CreatedAt.Format(time.RFC3339),
+		"payments":    payments,
+	}
+}
@@ -0,0 +1,286 @@
+package paytringtest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mandate statuses used by the fake.
+const (
+	MandateCreated   = "created"
+	MandateActive    = "active"
+	MandatePaused    = "paused"
+	MandateCancelled = "cancelled"
+)
+
+// Mandate returns a copy of the stored mandate.
+func (s *Server) Mandate(mandateId string) (Mandate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mandates[mandateId]
+	if !ok {
+		return Mandate{}, false
+	}
+	return *m, true
+}
+
+// AuthorizeMandate activates a created mandate, as a customer approving it
+// in their UPI app or on the hosted page would.
+func (s *Server) AuthorizeMandate(mandateId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.mandates[mandateId]
+	if !ok || m.Status != MandateCreated {
+		return false
+	}
+	m.Status = MandateActive
+	return true
+}
+
+func createPlan(s *Server, params map[string]interface{}) (int, interface{}) {
+	amount, err := strconv.ParseInt(str(params, "amount"), 10, 64)
+
+	invalid := map[string]string{}
+	if str(params, "name") == "" {
+		invalid["name"] = "The name field is required."
+	}
+	if err != nil || amount <= 0 {
+		invalid["amount"] = "The amount must be greater than 0."
+	}
+	switch str(params, "interval") {
+	case "day", "week", "month", "year":
+	default:
+		invalid["interval"] = "The selected interval is invalid."
+	}
+	intervalCount, err := strconv.Atoi(str(params, "interval_count"))
+	if err != nil || intervalCount <= 0 {
+		invalid["interval_count"] = "The interval count must be at least 1."
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	p := &Plan{
+		PlanId:        s.nextID(),
+		Name:          str(params, "name"),
+		Amount:        amount,
+		Currency:      str(params, "currency"),
+		Interval:      str(params, "interval"),
+		IntervalCount: intervalCount,
+		Description:   str(params, "description"),
+	}
+	s.plans[p.PlanId] = p
+
+	return success(map[string]interface{}{"plan": planJSON(p)})
+}
+
+func fetchPlan(s *Server, params map[string]interface{}) (int, interface{}) {
+	p, found := s.plans[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Plan not found")
+	}
+	return success(map[string]interface{}{"plan": planJSON(p)})
+}
+
+func createMandate(s *Server, params map[string]interface{}) (int, interface{}) {
+	maxAmount, err := strconv.ParseInt(str(params, "max_amount"), 10, 64)
+
+	invalid := map[string]string{}
+	if str(params, "receipt_id") == "" {
+		invalid["receipt_id"] = "The receipt id field is required."
+	}
+	if err != nil || maxAmount <= 0 {
+		invalid["max_amount"] = "The max amount must be greater than 0."
+	}
+	if planId := str(params, "plan_id"); planId != "" {
+		if _, found := s.plans[planId]; !found {
+			invalid["plan_id"] = "The selected plan id is invalid."
+		}
+	} else if str(params, "frequency") == "" {
+		invalid["frequency"] = "The frequency field is required when plan id is not present."
+	}
+	switch str(params, "method") {
+	case "upi":
+		if !strings.Contains(str(params, "vpa"), "@") {
+			invalid["vpa"] = "The vpa field is required for UPI mandates."
+		}
+	case "enach":
+		if _, isMap := params["bank_account"].(map[string]interface{}); !isMap {
+			invalid["bank_account"] = "The bank account field is required for e-NACH mandates."
+		}
+	case "card":
+	default:
+		invalid["method"] = "The selected method is invalid."
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	m := &Mandate{
+		MandateId: s.nextID(),
+		ReceiptId: str(params, "receipt_id"),
+		Method:    str(params, "method"),
+		Status:    MandateCreated,
+		MaxAmount: maxAmount,
+		Currency:  str(params, "currency"),
+		PlanId:    str(params, "plan_id"),
+		Frequency: str(params, "frequency"),
+		Customer: map[string]string{
+			"name":  str(params, "cname"),
+			"email": str(params, "email"),
+			"phone": str(params, "phone"),
+		},
+	}
+	s.mandates[m.MandateId] = m
+
+	return success(map[string]interface{}{"mandate": s.mandateJSON(m)})
+}
+
+func fetchMandate(s *Server, params map[string]interface{}) (int, interface{}) {
+	m, found := s.mandates[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Mandate not found")
+	}
+	return success(map[string]interface{}{"mandate": s.mandateJSON(m)})
+}
+
+func notifyPreDebit(s *Server, params map[string]interface{}) (int, interface{}) {
+	m, found := s.mandates[str(params, "mandate_id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Mandate not found")
+	}
+	if m.Status != MandateActive {
+		return http.StatusUnprocessableEntity, errorBody("Mandate is not active")
+	}
+
+	amount, err := strconv.ParseInt(str(params, "amount"), 10, 64)
+	invalid := map[string]string{}
+	if err != nil || amount <= 0 || amount > m.MaxAmount {
+		invalid["amount"] = "The amount must be between 1 and the mandate max amount."
+	}
+	debitAt, err := time.Parse(time.RFC3339, str(params, "debit_at"))
+	if err != nil || debitAt.Before(time.Now().Add(24*time.Hour)) {
+		invalid["debit_at"] = "The debit at must be at least 24 hours from now."
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	n := &Notice{NoticeId: s.nextID(), MandateId: m.MandateId, Amount: amount, DebitAt: debitAt}
+	s.notices[n.NoticeId] = n
+	m.NoticeIds = append(m.NoticeIds, n.NoticeId)
+
+	return success(map[string]interface{}{"notice": map[string]interface{}{
+		"notice_id":  n.NoticeId,
+		"mandate_id": n.MandateId,
+		"amount":     strconv.FormatInt(n.Amount, 10),
+		"currency":   m.Currency,
+		"debit_at":   n.DebitAt.UTC().Format(time.RFC3339),
+	}})
+}
+
+// executeDebit charges an active mandate right away. The fake does not wait
+// for the notice's debit_at.
+func executeDebit(s *Server, params map[string]interface{}) (int, interface{}) {
+	m, found := s.mandates[str(params, "mandate_id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Mandate not found")
+	}
+	if m.Status != MandateActive {
+		return http.StatusUnprocessableEntity, errorBody("Mandate cannot be debited in status " + m.Status)
+	}
+
+	n, found := s.notices[str(params, "notice_id")]
+	if !found || n.MandateId != m.MandateId {
+		return fieldErrors(map[string]string{"notice_id": "The selected notice id is invalid."})
+	}
+	if n.OrderId != "" {
+		return http.StatusUnprocessableEntity, errorBody("Notice was already debited")
+	}
+	amount, err := strconv.ParseInt(str(params, "amount"), 10, 64)
+	if err != nil || amount <= 0 || amount > n.Amount {
+		return fieldErrors(map[string]string{"amount": "The amount may not be greater than the notified amount."})
+	}
+
+	o := &Order{
+		OrderId:     s.nextID(),
+		ReceiptId:   str(params, "receipt_id"),
+		Amount:      amount,
+		Currency:    m.Currency,
+		Status:      StatusSuccess,
+		Pg:          s.DefaultPg,
+		AutoCapture: true,
+		Customer:    m.Customer,
+	}
+	s.orders[o.OrderId] = o
+	if o.ReceiptId != "" {
+		s.receipts[o.ReceiptId] = o.OrderId
+	}
+	n.OrderId = o.OrderId
+	m.OrderIds = append(m.OrderIds, o.OrderId)
+
+	return success(map[string]interface{}{"debit": map[string]interface{}{
+		"order_id":     o.OrderId,
+		"mandate_id":   m.MandateId,
+		"amount":       strconv.FormatInt(o.Amount, 10),
+		"currency":     o.Currency,
+		"order_status": o.Status,
+	}})
+}
+
+func pauseMandate(s *Server, params map[string]interface{}) (int, interface{}) {
+	return s.moveMandate(params, MandatePaused, MandateActive)
+}
+
+func resumeMandate(s *Server, params map[string]interface{}) (int, interface{}) {
+	return s.moveMandate(params, MandateActive, MandatePaused)
+}
+
+func cancelMandate(s *Server, params map[string]interface{}) (int, interface{}) {
+	return s.moveMandate(params, MandateCancelled, MandateCreated, MandateActive, MandatePaused)
+}
+
+// moveMandate sets the mandate named by params to status when it is in one
+// of the from statuses.
+func (s *Server) moveMandate(params map[string]interface{}, status string, from ...string) (int, interface{}) {
+	m, found := s.mandates[str(params, "id")]
+	if !found {
+		return http.StatusNotFound, errorBody("Mandate not found")
+	}
+	for _, allowed := range from {
+		if m.Status == allowed {
+			m.Status = status
+			return success(map[string]interface{}{"mandate": s.mandateJSON(m)})
+		}
+	}
+	return http.StatusUnprocessableEntity, errorBody("Mandate cannot move from " + m.Status + " to " + status)
+}
+
+func planJSON(p *Plan) map[string]interface{} {
+	return map[string]interface{}{
+		"plan_id":        p.PlanId,
+		"name":           p.Name,
+		"amount":         strconv.FormatInt(p.Amount, 10),
+		"currency":       p.Currency,
+		"interval":       p.Interval,
+		"interval_count": p.IntervalCount,
+		"description":    p.Description,
+	}
+}
+
+func (s *Server) mandateJSON(m *Mandate) map[string]interface{} {
+	return map[string]interface{}{
+		"mandate_id":     m.MandateId,
+		"receipt_id":     m.ReceiptId,
+		"method":         m.Method,
+		"mandate_status": m.Status,
+		"max_amount":     strconv.FormatInt(m.MaxAmount, 10),
+		"currency":       m.Currency,
+		"plan_id":        m.PlanId,
+		"frequency":      m.Frequency,
+		"url":            strings.TrimSuffix(s.URL, "api/") + "mandate/" + m.MandateId,
+		"customer":       m.Customer,
+	}
+}
@@ -0,0 +1,538 @@
+// Package paytringtest provides an in-process fake of the Paytring API for
+// tests that must run offline.
+//
+//	server := paytringtest.NewServer("test_key", "test_secret")
+//	defer server.Close()
+//
+//	client := paytring.NewClient("test_key", "test_secret", paytring.WithBaseURL(server.URL))
+//
+// Orders, refunds, payment links, mandates, card tokens and customers live
+// in memory. Requests are checked for Basic auth,
+// the API key and, on endpoints the SDK signs, the hash. Failures can be
+// scripted per endpoint with FailNext.
+package paytringtest
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Order statuses used by the fake.
+const (
+	StatusCreated           = "created"
+	StatusPending           = "pending"
+	StatusAuthorized        = "authorized"
+	StatusSuccess           = "success"
+	StatusFailed            = "failed"
+	StatusCancelled         = "cancelled"
+	StatusRefunded          = "refunded"
+	StatusPartiallyRefunded = "partially_refunded"
+)
+
+// Order is an order held by the fake server.
+type Order struct {
+	OrderId     string
+	ReceiptId   string
+	Amount      int64
+	Currency    string
+	Status      string
+	Pg          string
+	PgPoolId    string
+	Method      string
+	Code        string
+	AutoCapture bool
+	Refunded    int64
+	Customer    map[string]string
+	Notes       map[string]interface{}
+}
+
+// Refund is a refund held by the fake server.
+type Refund struct {
+	RefundId  string
+	OrderId   string
+	Amount    int64
+	Currency  string
+	Status    string
+	CreatedAt time.Time
+}
+
+// Link is a payment link held by the fake server.
+type Link struct {
+	LinkId      string
+	ReceiptId   string
+	Amount      int64
+	Currency    string
+	Status      string
+	Description string
+	Customer    map[string]string
+	Notes       map[string]interface{}
+	ExpireAt    time.Time
+	Notify      []string
+	// Sent counts how often the link was sent to the customer.
+	Sent      int
+	OrderIds  []string
+	CreatedAt time.Time
+}
+
+// Plan is a subscription plan held by the fake server.
+type Plan struct {
+	PlanId        string
+	Name          string
+	Amount        int64
+	Currency      string
+	Interval      string
+	IntervalCount int
+	Description   string
+}
+
+// Mandate is a recurring payment mandate held by the fake server.
+type Mandate struct {
+	MandateId string
+	ReceiptId string
+	Method    string
+	Status    string
+	MaxAmount int64
+	Currency  string
+	PlanId    string
+	Frequency string
+	Customer  map[string]string
+	// NoticeIds are the pre-debit notifications sent, OrderIds the debits
+	// made.
+	NoticeIds []string
+	OrderIds  []string
+}
+
+// Notice is a pre-debit notification held by the fake server.
+type Notice struct {
+	NoticeId  string
+	MandateId string
+	Amount    int64
+	DebitAt   time.Time
+	// OrderId is the debit made against the notice, once there is one.
+	OrderId string
+}
+
+// Token is a saved card held by the fake server. Like Paytring, it keeps
+// no more of the card than its last four digits.
+type Token struct {
+	TokenId     string
+	CustomerId  string
+	Brand       string
+	Last4       string
+	ExpiryMonth string
+	ExpiryYear  string
+	HolderName  string
+	Status      string
+	CreatedAt   time.Time
+}
+
+// Customer is a customer held by the fake server. Instruments map
+// instrument ids to their JSON.
+type Customer struct {
+	CustomerId        string
+	Name              string
+	Email             string
+	Phone             string
+	Notes             map[string]interface{}
+	BillingAddresses  []map[string]interface{}
+	ShippingAddresses []map[string]interface{}
+	Instruments       map[string]map[string]interface{}
+	CreatedAt         time.Time
+}
+
+// Failure scripts how an endpoint misbehaves.
+type Failure struct {
+	// Status is the HTTP status to answer with, 500 when zero.
+	Status int
+	// Message is sent as Paytring's error message.
+	Message string
+	// Body, when set, is sent verbatim instead of an error payload.
+	Body string
+	// Delay is waited before answering.
+	Delay time.Duration
+	// Times is how many requests fail, 1 when zero.
+	Times int
+	// AfterProcessing lets the request take effect before the failure is
+	// returned, like a timeout after Paytring accepted a call.
+	AfterProcessing bool
+}
+
+// Server is a fake Paytring API.
+type Server struct {
+	// URL is the base URL to pass to paytring.WithBaseURL.
+	URL string
+
+	ApiKey    string
+	ApiSecret string
+
+	// DefaultPg is reported as the gateway of orders that were not pinned
+	// to one.
+	DefaultPg string
+
+	server *httptest.Server
+
+	mu        sync.Mutex
+	seq       int64
+	orders    map[string]*Order
+	receipts  map[string]string
+	refunds   map[string]*Refund
+	links     map[string]*Link
+	plans     map[string]*Plan
+	mandates  map[string]*Mandate
+	notices   map[string]*Notice
+	tokens    map[string]*Token
+	customers map[string]*Customer
+	rates     map[string]string
+	failures  map[string][]*Failure
+	calls     map[string]int
+}
+
+// NewServer starts a fake server accepting the given credentials.
+func NewServer(apiKey string, apiSecret string) *Server {
+	s := &Server{
+		ApiKey:    apiKey,
+		ApiSecret: apiSecret,
+		DefaultPg: "razorpay",
+		seq:       771606428862383000,
+		orders:    map[string]*Order{},
+		receipts:  map[string]string{},
+		refunds:   map[string]*Refund{},
+		links:     map[string]*Link{},
+		plans:     map[string]*Plan{},
+		mandates:  map[string]*Mandate{},
+		notices:   map[string]*Notice{},
+		tokens:    map[string]*Token{},
+		customers: map[string]*Customer{},
+		rates:     map[string]string{"USD:INR": "83.12", "INR:USD": "0.012"},
+		failures:  map[string][]*Failure{},
+		calls:     map[string]int{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL + "/api/"
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// FailNext makes the next f.Times requests to endpoint, e.g.
+// "v2/order/create", fail as described by f.
+func (s *Server) FailNext(endpoint string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	if f.Status == 0 {
+		f.Status = http.StatusInternalServerError
+	}
+	s.failures[endpoint] = append(s.failures[endpoint], &f)
+}
+
+// Calls returns how many requests endpoint received.
+func (s *Server) Calls(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[endpoint]
+}
+
+// AddOrder stores o as if it had been created. OrderId is generated when
+// empty and Status defaults to StatusCreated.
+func (s *Server) AddOrder(o Order) Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o.OrderId == "" {
+		o.OrderId = s.nextID()
+	}
+	if o.Status == "" {
+		o.Status = StatusCreated
+	}
+	if o.Currency == "" {
+		o.Currency = "INR"
+	}
+	s.orders[o.OrderId] = &o
+	if o.ReceiptId != "" {
+		s.receipts[o.ReceiptId] = o.OrderId
+	}
+	return o
+}
+
+// AddRefund stores r as if it had been issued.
+func (s *Server) AddRefund(r Refund) Refund {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.RefundId == "" {
+		r.RefundId = s.nextID()
+	}
+	if r.Status == "" {
+		r.Status = "processed"
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	s.refunds[r.RefundId] = &r
+	return r
+}
+
+// Order returns a copy of the stored order.
+func (s *Server) Order(orderId string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[orderId]
+	if !ok {
+		return Order{}, false
+	}
+	return *o, true
+}
+
+// SetOrderStatus overrides the status of an order, e.g. to settle a UPI
+// collect request.
+func (s *Server) SetOrderStatus(orderId string, status string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[orderId]
+	if ok {
+		o.Status = status
+	}
+	return ok
+}
+
+// CompletePayment marks a processed order as paid: StatusSuccess, or
+// StatusAuthorized when the order does not auto capture.
+func (s *Server) CompletePayment(orderId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[orderId]
+	if !ok {
+		return false
+	}
+	if o.AutoCapture {
+		o.Status = StatusSuccess
+	} else {
+		o.Status = StatusAuthorized
+	}
+	return true
+}
+
+// SetRate sets the rate CurrencyConversion reports for from to to.
+func (s *Server) SetRate(from string, to string, rate string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[from+":"+to] = rate
+}
+
+func (s *Server) nextID() string {
+	s.seq++
+	return strconv.FormatInt(s.seq, 10)
+}
+
+type handler func(s *Server, params map[string]interface{}) (int, interface{})
+
+type route struct {
+	handle handler
+	// signed routes carry a hash made with MakeHash.
+	signed bool
+	// noAuth routes are called without Basic auth by the SDK.
+	noAuth bool
+}
+
+var routes = map[string]route{
+	"v2/order/create":               {handle: createOrder},
+	"v2/order/fetch":                {handle: fetchOrder},
+	"v2/order/fetch/receipt":        {handle: fetchOrderByReceipt, signed: true},
+	"v1/order/process":              {handle: processOrder, signed: true, noAuth: true},
+	"v2/order/cancel":               {handle: cancelOrder},
+	"v2/order/capture":              {handle: captureOrder},
+	"v2/order/refund":               {handle: refundOrder},
+	"v2/order/refund/partial":       {handle: partialRefund, signed: true},
+	"v2/order/refund/fetch":         {handle: fetchRefund},
+	"v2/order/refund/attempts":      {handle: refundAttempts},
+	"v1/info/vpa":                   {handle: validateVPA, signed: true},
+	"v1/health/bin":                 {handle: validateBin, signed: true},
+	"v1/currency/get":               {handle: currencyConversion, signed: true},
+	"v2/link/create":                {handle: createLink},
+	"v2/link/fetch":                 {handle: fetchLink},
+	"v2/link/list":                  {handle: listLinks},
+	"v2/link/cancel":                {handle: cancelLink},
+	"v2/link/resend":                {handle: resendLink},
+	"v2/subscription/plan/create":   {handle: createPlan},
+	"v2/subscription/plan/fetch":    {handle: fetchPlan},
+	"v2/mandate/create":             {handle: createMandate},
+	"v2/mandate/fetch":              {handle: fetchMandate},
+	"v2/mandate/notify":             {handle: notifyPreDebit, signed: true},
+	"v2/mandate/debit":              {handle: executeDebit, signed: true},
+	"v2/mandate/pause":              {handle: pauseMandate},
+	"v2/mandate/resume":             {handle: resumeMandate},
+	"v2/mandate/cancel":             {handle: cancelMandate},
+	"v2/card/token/create":          {handle: createToken, signed: true},
+	"v2/card/token/list":            {handle: listTokens},
+	"v2/card/token/delete":          {handle: deleteToken},
+	"v2/customer/create":            {handle: createCustomer},
+	"v2/customer/update":            {handle: updateCustomer},
+	"v2/customer/fetch":             {handle: fetchCustomer},
+	"v2/customer/address/add":       {handle: addCustomerAddress},
+	"v2/customer/instrument/add":    {handle: attachInstrument},
+	"v2/customer/instrument/remove": {handle: detachInstrument},
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/api/")
+
+	rt, ok := routes[endpoint]
+	if !ok || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusNotFound, errorBody("Route not found"))
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[endpoint]++
+	failure := s.takeFailure(endpoint)
+	s.mu.Unlock()
+
+	if failure != nil && failure.Delay > 0 {
+		select {
+		case <-time.After(failure.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failure != nil && !failure.AfterProcessing {
+		writeFailure(w, failure)
+		return
+	}
+
+	status, body := s.handle(r, rt)
+
+	if failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+	writeJSON(w, status, body)
+}
+
+func (s *Server) handle(r *http.Request, rt route) (int, interface{}) {
+	if !rt.noAuth && !s.authorized(r) {
+		return http.StatusUnauthorized, errorBody("Unauthenticated")
+	}
+
+	var params map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return http.StatusBadRequest, errorBody("Invalid JSON body")
+	}
+
+	if params["key"] != s.ApiKey {
+		return http.StatusUnauthorized, errorBody("Invalid key")
+	}
+	if rt.signed && !s.validHash(params) {
+		return http.StatusUnauthorized, errorBody("Invalid hash")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return rt.handle(s, params)
+}
+
+func (s *Server) takeFailure(endpoint string) *Failure {
+	queue := s.failures[endpoint]
+	if len(queue) == 0 {
+		return nil
+	}
+	f := queue[0]
+	f.Times--
+	if f.Times <= 0 {
+		s.failures[endpoint] = queue[1:]
+	}
+	return f
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(s.ApiKey+":"+s.ApiSecret))
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) == 1
+}
+
+// validHash mirrors paytring.Api.VerifyHash. It is duplicated so that the
+// SDK's own tests can use this package without an import cycle.
+func (s *Server) validHash(params map[string]interface{}) bool {
+	received, _ := params["hash"].(string)
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var values strings.Builder
+	for _, key := range keys {
+		if v, ok := params[key].(string); ok {
+			values.WriteString(v)
+			values.WriteString("|")
+		}
+	}
+	values.WriteString(s.ApiSecret)
+
+	expected := fmt.Sprintf("%x", sha512.Sum512([]byte(values.String())))
+	return subtle.ConstantTimeCompare([]byte(received), []byte(expected)) == 1
+}
+
+func writeFailure(w http.ResponseWriter, f *Failure) {
+	if f.Body != "" {
+		w.WriteHeader(f.Status)
+		w.Write([]byte(f.Body))
+		return
+	}
+	message := f.Message
+	if message == "" {
+		message = http.StatusText(f.Status)
+	}
+	writeJSON(w, f.Status, errorBody(message))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func errorBody(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"status": false,
+		"error":  map[string]interface{}{"message": message},
+	}
+}
+
+// fieldErrors builds a validation failure the way Paytring does, with the
+// field map JSON encoded inside the message.
+func fieldErrors(fields map[string]string) (int, interface{}) {
+	messages := map[string][]string{}
+	for field, message := range fields {
+		messages[field] = []string{message}
+	}
+	encoded, _ := json.Marshal(messages)
+	return http.StatusUnprocessableEntity, map[string]interface{}{
+		"status": false,
+		"error":  map[string]interface{}{"code": "validation_error", "message": string(encoded)},
+	}
+}
+
+func success(fields map[string]interface{}) (int, interface{}) {
+	fields["status"] = true
+	return http.StatusOK, fields
+}
+
+func str(params map[string]interface{}, key string) string {
+	s, _ := params[key].(string)
+	return s
+}
@@ -0,0 +1,70 @@
+package paytringtest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	paytring "github.com/paytring/go-sdk"
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(t *testing.T) (*paytring.Api, *paytringtest.Server) {
+	server := paytringtest.NewServer("test_key", "test_secret")
+	t.Cleanup(server.Close)
+	return paytring.NewClient("test_key", "test_secret", paytring.WithBaseURL(server.URL)), server
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	client, server := newClient(t)
+
+	created, err := client.CreateOrder(paytring.NewMoney(1000, "INR"), "R1", "https://example.com/callback", paytring.Customer{Name: "John Doe"})
+	assert.NoError(t, err)
+
+	_, err = client.ProcessOrder(created.OrderId, "upi", "collect", paytring.PaymentData{Vpa: "john@upi"}, "")
+	assert.NoError(t, err)
+
+	server.CompletePayment(created.OrderId)
+	order, err := client.CaptureOrder(created.OrderId)
+	assert.NoError(t, err)
+	assert.Equal(t, paytring.OrderCaptured, order.Status)
+
+	refund, err := client.PartialRefund(created.OrderId, paytring.NewMoney(400, "INR"))
+	assert.NoError(t, err)
+	assert.Equal(t, paytring.Amount(400), refund.Amount)
+
+	order, err = client.FetchOrder(created.OrderId, "normal")
+	assert.NoError(t, err)
+	assert.Equal(t, paytring.OrderPartiallyRefunded, order.Status)
+	assert.Equal(t, paytring.Amount(600), order.RefundableAmount())
+
+	_, err = client.PartialRefund(created.OrderId, paytring.NewMoney(700, "INR"))
+	var apiErr *paytring.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "The amount exceeds the refundable amount.", apiErr.FieldError("amount"))
+}
+
+func TestWrongCredentialsAreRejected(t *testing.T) {
+	_, server := newClient(t)
+	client := paytring.NewClient("test_key", "wrong_secret", paytring.WithBaseURL(server.URL))
+
+	_, err := client.FetchOrder("1", "normal")
+	var apiErr *paytring.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestScriptedFailureAfterProcessing(t *testing.T) {
+	client, server := newClient(t)
+	server.FailNext("v2/order/create", paytringtest.Failure{Status: http.StatusGatewayTimeout, AfterProcessing: true})
+
+	result, err := client.CreateOrderIdempotent(paytring.NewMoney(1000, "INR"), "R2", "https://example.com/callback", paytring.Customer{})
+	assert.NoError(t, err)
+	assert.Equal(t, paytring.OutcomeRecovered, result.Outcome)
+	assert.Equal(t, 1, server.Calls("v2/order/create"))
+
+	stored, found := server.Order(result.OrderId())
+	assert.True(t, found)
+	assert.Equal(t, "R2", stored.ReceiptId)
+}
@@ -0,0 +1,131 @@
+package paytringtest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Card token statuses used by the fake.
+const (
+	TokenActive    = "active"
+	TokenSuspended = "suspended"
+	TokenDeleted   = "deleted"
+)
+
+// Token returns a copy of the stored card token.
+func (s *Server) Token(tokenId string) (Token, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[tokenId]
+	if !ok {
+		return Token{}, false
+	}
+	return *t, true
+}
+
+// SuspendToken suspends an active token, as a network does when the card
+// is blocked.
+func (s *Server) SuspendToken(tokenId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[tokenId]
+	if !ok || t.Status != TokenActive {
+		return false
+	}
+	t.Status = TokenSuspended
+	return true
+}
+
+func createToken(s *Server, params map[string]interface{}) (int, interface{}) {
+	card, _ := params["card"].(map[string]interface{})
+	number := str(card, "number")
+
+	invalid := map[string]string{}
+	if str(params, "customer_id") == "" {
+		invalid["customer_id"] = "The customer id field is required."
+	}
+	if str(params, "consent") != "true" {
+		invalid["consent"] = "The consent must be accepted."
+	}
+	if len(number) < 12 {
+		invalid["card.number"] = "The card number is invalid."
+	}
+	if len(invalid) > 0 {
+		return fieldErrors(invalid)
+	}
+
+	t := &Token{
+		TokenId:     s.nextID(),
+		CustomerId:  str(params, "customer_id"),
+		Brand:       cardBrand(number),
+		Last4:       number[len(number)-4:],
+		ExpiryMonth: str(card, "expiry_month"),
+		ExpiryYear:  str(card, "expiry_year"),
+		HolderName:  str(card, "holder_name"),
+		Status:      TokenActive,
+		CreatedAt:   time.Now(),
+	}
+	s.tokens[t.TokenId] = t
+
+	return success(map[string]interface{}{"token": s.tokenJSON(t)})
+}
+
+func listTokens(s *Server, params map[string]interface{}) (int, interface{}) {
+	var matched []*Token
+	for _, t := range s.tokens {
+		if t.CustomerId == str(params, "customer_id") && t.Status != TokenDeleted {
+			matched = append(matched, t)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].TokenId < matched[j].TokenId })
+
+	tokens := []interface{}{}
+	for _, t := range matched {
+		tokens = append(tokens, s.tokenJSON(t))
+	}
+	return success(map[string]interface{}{"tokens": tokens})
+}
+
+func deleteToken(s *Server, params map[string]interface{}) (int, interface{}) {
+	t, found := s.tokens[str(params, "id")]
+	if !found || t.Status == TokenDeleted {
+		return http.StatusNotFound, errorBody("Token not found")
+	}
+	t.Status = TokenDeleted
+	return success(map[string]interface{}{"token": s.tokenJSON(t)})
+}
+
+// tokenJSON renders t with a fresh cryptogram.
+func (s *Server) tokenJSON(t *Token) map[string]interface{} {
+	cryptogram := sha256.Sum256([]byte(t.TokenId + ":" + s.nextID()))
+	return map[string]interface{}{
+		"token_id":     t.TokenId,
+		"customer_id":  t.CustomerId,
+		"brand":        t.Brand,
+		"last4":        t.Last4,
+		"expiry_month": t.ExpiryMonth,
+		"expiry_year":  t.ExpiryYear,
+		"holder_name":  t.HolderName,
+		"token_status": t.Status,
+		"cryptogram":   base64.StdEncoding.EncodeToString(cryptogram[:20]),
+		"created_at":   t.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// cardBrand is a rough guess of the network of number, enough for tests.
+func cardBrand(number string) string {
+	switch number[0] {
+	case '4':
+		return "visa"
+	case '5', '2':
+		return "mastercard"
+	case '3':
+		return "amex"
+	case '6', '8':
+		return "rupay"
+	}
+	return ""
+}
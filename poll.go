@@ -0,0 +1,203 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PollPolicy controls how WaitForOrder and WaitForRefund poll. The overall
+// time limit comes from the context.
+type PollPolicy struct {
+	// Interval is the wait before the second poll.
+	Interval time.Duration
+	// Multiplier grows the interval after every poll. Values below 1 keep
+	// it constant.
+	Multiplier float64
+	// MaxInterval caps the interval, zero means no cap.
+	MaxInterval time.Duration
+	// FetchType is passed to FetchOrder, "normal" when empty.
+	FetchType string
+}
+
+// DefaultPollPolicy suits UPI collect requests, which usually settle within
+// a minute or two.
+func DefaultPollPolicy() PollPolicy {
+	return PollPolicy{
+		Interval:    2 * time.Second,
+		Multiplier:  1.5,
+		MaxInterval: 15 * time.Second,
+		FetchType:   "normal",
+	}
+}
+
+func (p PollPolicy) next(interval time.Duration) time.Duration {
+	if p.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * p.Multiplier)
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+// ErrTerminalStatus is wrapped by WaitError when polling stopped because
+// the order or refund can no longer change, without the predicate matching.
+var ErrTerminalStatus = errors.New("reached a terminal status")
+
+// WaitError is returned when WaitForOrder or WaitForRefund gives up. It
+// wraps either ErrTerminalStatus or the context's error, and carries the
+// last status observed.
+type WaitError struct {
+	Id         string
+	LastStatus string
+	Err        error
+}
+
+func (e *WaitError) Error() string {
+	if e.LastStatus == "" {
+		return fmt.Sprintf("waiting for %s: %v", e.Id, e.Err)
+	}
+	return fmt.Sprintf("waiting for %s, last status %s: %v", e.Id, e.LastStatus, e.Err)
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// OrderSettled reports whether the payment left the created and pending
+// states. It is the default predicate of WaitForOrder.
+func OrderSettled(order *Order) bool {
+	switch order.Status.Normalize() {
+	case OrderCreated, OrderPending:
+		return false
+	}
+	return true
+}
+
+// OrderInStatus returns a predicate matching any of statuses.
+func OrderInStatus(statuses ...OrderStatus) func(*Order) bool {
+	return func(order *Order) bool {
+		for _, status := range statuses {
+			if order.Status.Normalize() == status.Normalize() {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WaitForOrder polls FetchOrder until until returns true, the order reaches
+// a terminal status or ctx ends. A nil until means OrderSettled. The last
+// order fetched is returned alongside any *WaitError.
+func (c *Api) WaitForOrder(ctx context.Context, orderId string, until func(*Order) bool, policy PollPolicy) (*Order, error) {
+
+	if until == nil {
+		until = OrderSettled
+	}
+	fetchType := policy.FetchType
+	if fetchType == "" {
+		fetchType = "normal"
+	}
+
+	var last *Order
+	err := c.poll(ctx, policy, func() (bool, error) {
+		order, err := c.FetchOrderCtx(ctx, orderId, fetchType)
+		if err != nil {
+			return false, err
+		}
+		last = order
+		if until(order) {
+			return true, nil
+		}
+		if order.Status.Terminal() {
+			return true, ErrTerminalStatus
+		}
+		return false, nil
+	})
+
+	if err != nil {
+		waitErr := &WaitError{Id: orderId, Err: err}
+		if last != nil {
+			waitErr.LastStatus = string(last.Status)
+		}
+		return last, waitErr
+	}
+	return last, nil
+}
+
+// RefundSettled reports whether the refund was processed or failed. It is
+// the default predicate of WaitForRefund.
+func RefundSettled(refund *Refund) bool {
+	return refundTerminal(refund.Status)
+}
+
+func refundTerminal(status string) bool {
+	switch strings.ToLower(status) {
+	case "processed", "success", "completed", "failed", "rejected", "cancelled":
+		return true
+	}
+	return false
+}
+
+// WaitForRefund polls FetchRefundStatus until until returns true, the
+// refund settles or ctx ends. A nil until means RefundSettled.
+func (c *Api) WaitForRefund(ctx context.Context, refundId string, until func(*Refund) bool, policy PollPolicy) (*Refund, error) {
+
+	if until == nil {
+		until = RefundSettled
+	}
+
+	var last *Refund
+	err := c.poll(ctx, policy, func() (bool, error) {
+		refund, err := c.FetchRefundStatusCtx(ctx, refundId)
+		if err != nil {
+			return false, err
+		}
+		last = refund
+		if until(refund) {
+			return true, nil
+		}
+		if refundTerminal(refund.Status) {
+			return true, ErrTerminalStatus
+		}
+		return false, nil
+	})
+
+	if err != nil {
+		waitErr := &WaitError{Id: refundId, Err: err}
+		if last != nil {
+			waitErr.LastStatus = last.Status
+		}
+		return last, waitErr
+	}
+	return last, nil
+}
+
+// poll calls check until it reports done, returns an error that is not
+// worth retrying, or ctx ends.
+func (c *Api) poll(ctx context.Context, policy PollPolicy, check func() (bool, error)) error {
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = DefaultPollPolicy().Interval
+	}
+	for {
+		done, err := check()
+		if done {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil && !c.RetryPolicy.shouldRetry(err) {
+			return err
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			return err
+		}
+		interval = policy.next(interval)
+	}
+}
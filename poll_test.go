@@ -0,0 +1,81 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paytring/go-sdk/paytringtest"
+	"github.com/stretchr/testify/assert"
+)
+
+var fastPolling = PollPolicy{Interval: 2 * time.Millisecond, Multiplier: 2, MaxInterval: 10 * time.Millisecond}
+
+func TestWaitForOrder(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_WAIT", Amount: 1000, Status: paytringtest.StatusPending, AutoCapture: true})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		server.CompletePayment("TEST_ORDER_ID_WAIT")
+	}()
+
+	order, err := client.WaitForOrder(context.Background(), "TEST_ORDER_ID_WAIT", nil, fastPolling)
+	assert.NoError(t, err)
+	assert.Equal(t, OrderCaptured, order.Status)
+	assert.True(t, server.Calls("v2/order/fetch") > 1)
+}
+
+func TestWaitForOrderTimeout(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_WAIT", Amount: 1000, Status: paytringtest.StatusPending})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	order, err := client.WaitForOrder(ctx, "TEST_ORDER_ID_WAIT", nil, fastPolling)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	var waitErr *WaitError
+	assert.True(t, errors.As(err, &waitErr))
+	assert.Equal(t, "pending", waitErr.LastStatus)
+	assert.Equal(t, OrderPending, order.Status)
+}
+
+func TestWaitForOrderStopsOnTerminalStatus(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_WAIT", Amount: 1000, Status: paytringtest.StatusCancelled})
+
+	_, err := client.WaitForOrder(context.Background(), "TEST_ORDER_ID_WAIT", OrderInStatus(OrderCaptured), fastPolling)
+	assert.True(t, errors.Is(err, ErrTerminalStatus))
+	assert.Equal(t, 1, server.Calls("v2/order/fetch"))
+}
+
+func TestWaitForOrderPollsThroughUnknownStatus(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_UNKNOWN", Amount: 1000, Status: "on_hold"})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		server.AddOrder(paytringtest.Order{OrderId: "TEST_ORDER_ID_UNKNOWN", Amount: 1000, Status: paytringtest.StatusSuccess})
+	}()
+
+	order, err := client.WaitForOrder(context.Background(), "TEST_ORDER_ID_UNKNOWN", OrderInStatus(OrderCaptured), fastPolling)
+	assert.NoError(t, err)
+	assert.Equal(t, OrderCaptured, order.Status)
+}
+
+func TestWaitForRefund(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddRefund(paytringtest.Refund{RefundId: "TEST_REFUND_ID_WAIT", Amount: 100, Status: "pending"})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		server.AddRefund(paytringtest.Refund{RefundId: "TEST_REFUND_ID_WAIT", Amount: 100, Status: "processed"})
+	}()
+
+	refund, err := client.WaitForRefund(context.Background(), "TEST_REFUND_ID_WAIT", nil, fastPolling)
+	assert.NoError(t, err)
+	assert.Equal(t, "processed", refund.Status)
+}
@@ -0,0 +1,195 @@
+package paytring
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces values that must never be shown, such as a CVV or the
+// API secret.
+const redacted = "[REDACTED]"
+
+var (
+	authPattern    = regexp.MustCompile(`(?i)\b(basic|bearer)\s+[A-Za-z0-9+/=._-]+`)
+	secretPattern  = regexp.MustCompile(`(?i)(\b(?:cvv|cryptogram)"?\s*[:=]\s*)("[^"]*"|[A-Za-z0-9+/=]+)`)
+	addressPattern = regexp.MustCompile(`([A-Za-z0-9._%+-]+)@([A-Za-z0-9.-]+)`)
+	panPattern     = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	phonePattern   = regexp.MustCompile(`(?:\+\d{1,3}[ -]?)?\b[6-9]\d{9}\b`)
+)
+
+// Redact masks the card data, contact details and credentials found in
+// text. Card numbers keep their first 6 and last 4 digits, CVVs, token
+// cryptograms and Basic or Bearer credentials are dropped, and VPAs,
+// emails and phone numbers keep just enough to be recognised. Any run of 13
+// to 19 digits that passes the Luhn check is taken for a card number,
+// whatever its brand. Every message the SDK logs or returns as an error
+// goes through it.
+func Redact(text string) string {
+	text = authPattern.ReplaceAllString(text, "$1 "+redacted)
+	text = secretPattern.ReplaceAllString(text, `$1"`+redacted+`"`)
+	text = panPattern.ReplaceAllStringFunc(text, func(number string) string {
+		if !LuhnValid(number) {
+			return number
+		}
+		return maskPAN(number)
+	})
+	text = phonePattern.ReplaceAllStringFunc(text, maskPhone)
+	text = addressPattern.ReplaceAllStringFunc(text, maskAddress)
+	return text
+}
+
+// idKeys hold the ids of orders, refunds and other objects. Their values
+// are shown as they are unless they look like a card number, so that long
+// numeric order ids passing the Luhn check by chance stay readable.
+var idKeys = map[string]bool{
+	"id":          true,
+	"order_id":    true,
+	"refund_id":   true,
+	"receipt_id":  true,
+	"customer_id": true,
+	"mandate_id":  true,
+	"plan_id":     true,
+	"token_id":    true,
+	"link_id":     true,
+	"notice_id":   true,
+	"vendor_id":   true,
+}
+
+// looksLikePAN reports whether number has the prefix and length of a known
+// card brand and passes the Luhn check.
+func looksLikePAN(number string) bool {
+	digits := cardDigits(number)
+	brand := DetectCardBrand(digits)
+	return brand != CardUnknown && validCardLength(brand, len(digits)) && LuhnValid(digits)
+}
+
+// maskPAN keeps the first 6 and last 4 digits of a card number.
+func maskPAN(number string) string {
+	digits := cardDigits(number)
+	if len(digits) < 13 {
+		return strings.Repeat("*", len(digits))
+	}
+	return digits[:6] + strings.Repeat("*", len(digits)-10) + digits[len(digits)-4:]
+}
+
+// maskAddress keeps the first character of the user part of an email
+// address or VPA, and the domain or bank handle.
+func maskAddress(address string) string {
+	user, domain, ok := strings.Cut(address, "@")
+	if !ok || user == "" {
+		return address
+	}
+	return user[:1] + "***@" + domain
+}
+
+// maskPhone keeps the last 4 digits of a phone number.
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return strings.Repeat("*", len(phone))
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// redactValue redacts v, the value of key in a request or response body.
+// The second result is false when the value must be dropped altogether.
+// The values of idKeys are only masked when they look like a card number.
+func redactValue(key string, v interface{}) (interface{}, bool) {
+	key = strings.ToLower(key)
+	switch key {
+	case "cvv", "cryptogram":
+		return nil, false
+	case "authorization":
+		return redacted, true
+	}
+	if s, isString := v.(string); isString && idKeys[key] {
+		if looksLikePAN(s) {
+			return maskPAN(s), true
+		}
+		return s, true
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return redactMap(v), true
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item, ok := redactValue("", item); ok {
+				out = append(out, item)
+			}
+		}
+		return out, true
+	case []map[string]interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			out = append(out, redactMap(item))
+		}
+		return out, true
+	case string:
+		switch key {
+		case "number", "card_number":
+			return maskPAN(v), true
+		case "phone":
+			return maskPhone(v), true
+		case "email", "vpa":
+			return maskAddress(v), true
+		}
+		return Redact(v), true
+	}
+	return v, true
+}
+
+// redactMap returns a redacted copy of m, leaving m untouched.
+func redactMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for key, v := range m {
+		if v, ok := redactValue(key, v); ok {
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// redactHeaders returns a copy of headers with credentials removed.
+func redactHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for key, v := range headers {
+		if strings.EqualFold(key, "Authorization") {
+			v = redacted
+		}
+		out[key] = Redact(v)
+	}
+	return out
+}
+
+// String shows p with the card number masked and the CVV and cryptogram
+// left out, so that printing or logging it is safe.
+func (p PaymentData) String() string {
+	return p.GoString()
+}
+
+func (p PaymentData) GoString() string {
+	cvv, cryptogram := "", ""
+	if p.Cvv != "" {
+		cvv = redacted
+	}
+	if p.Cryptogram != "" {
+		cryptogram = redacted
+	}
+	return fmt.Sprintf("paytring.PaymentData{Vpa:%q, CardNumber:%q, ExpiryMonth:%q, ExpiryYear:%q, Cvv:%q, HolderName:%q, Token:%q, Cryptogram:%q}",
+		maskAddress(p.Vpa), maskPAN(p.CardNumber), p.ExpiryMonth, p.ExpiryYear, cvv, p.HolderName, p.Token, cryptogram)
+}
+
+// String describes c without its secret or credentials.
+func (c *Api) String() string {
+	return c.GoString()
+}
+
+func (c *Api) GoString() string {
+	if c == nil {
+		return "(*paytring.Api)(nil)"
+	}
+	return fmt.Sprintf("&paytring.Api{ApiKey:%q, ApiSecret:%q, ApiUrl:%q, UserAgent:%q, CustomHeaders:%v}",
+		c.ApiKey, redacted, c.ApiUrl, c.UserAgent, redactHeaders(c.CustomHeaders))
+}
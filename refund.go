@@ -1,154 +1,146 @@
 package paytring
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"strconv"
+	"strings"
 )
 
-func (c *Api) RefundOrder(orderID string) (map[string]interface{}, error) {
+func (c *Api) RefundOrder(orderID string) (*Refund, error) {
+	return c.RefundOrderCtx(context.Background(), orderID)
+}
+
+func (c *Api) RefundOrderCtx(ctx context.Context, orderID string) (*Refund, error) {
+	if err := c.guard.check(orderID, "refund", (*Order).CanRefund); err != nil {
+		return nil, err
+	}
+
 	requestBody := map[string]interface{}{
 		"key":  c.ApiKey,
 		"id":   orderID,
 		"hash": "null",
 	}
 
-	body, err := json.Marshal(requestBody)
+	body, response, err := c.post(ctx, "RefundOrder", "v2/order/refund", c.MakeAuthHeader(), requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for RefundOrder: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.http.R().
-		SetHeaders(c.MakeAuthHeader()).
-		SetBody(body).
-		Post(c.ApiUrl + "v2/order/refund")
-
-	if err != nil {
-		return nil, fmt.Errorf("RefundOrder request failed: %w", err)
+	var refund Refund
+	if err := decodeResponse(body, "refund", response, &refund); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for RefundOrder: %w", err)
 	}
 
-	var bodyMap map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &bodyMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body for RefundOrder: %w", err)
-	}
+	c.guard.observeRefund(orderID, &refund)
 
-	return c.HandleResponse(bodyMap)
+	return &refund, nil
 }
 
-func (c *Api) FetchRefundStatus(refundID string) (map[string]interface{}, error) {
+func (c *Api) FetchRefundStatus(refundID string) (*Refund, error) {
+	return c.FetchRefundStatusCtx(context.Background(), refundID)
+}
+
+func (c *Api) FetchRefundStatusCtx(ctx context.Context, refundID string) (*Refund, error) {
 	requestBody := map[string]interface{}{
 		"key":  c.ApiKey,
 		"id":   refundID,
 		"hash": "null",
 	}
 
-	body, err := json.Marshal(requestBody)
+	body, response, err := c.post(ctx, "FetchRefundStatus", "v2/order/refund/fetch", c.MakeAuthHeader(), requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for FetchRefundStatus: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.http.R().
-		SetHeaders(c.MakeAuthHeader()).
-		SetBody(body).
-		Post(c.ApiUrl + "v2/order/refund/fetch")
-
-	if err != nil {
-		return nil, fmt.Errorf("FetchRefundStatus request failed: %w", err)
+	var refund Refund
+	if err := decodeResponse(body, "refund", response, &refund); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for FetchRefundStatus: %w", err)
 	}
 
-	var bodyMap map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &bodyMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body for FetchRefundStatus: %w", err)
-	}
+	return &refund, nil
+}
 
-	return c.HandleResponse(bodyMap)
+func (c *Api) PartialRefund(orderID string, amount Money) (*Refund, error) {
+	return c.PartialRefundCtx(context.Background(), orderID, amount)
 }
 
-func (c *Api) PartialRefund(orderID string, amount int64) (map[string]interface{}, error) {
+func (c *Api) PartialRefundCtx(ctx context.Context, orderID string, amount Money) (*Refund, error) {
+	if err := c.guard.checkRefund(orderID, amount); err != nil {
+		return nil, err
+	}
+
 	requestPayload := map[string]interface{}{
 		"key":    c.ApiKey,
 		"id":     orderID,
-		"amount": strconv.FormatInt(amount, 10), // Amount as string for hashing consistency
+		"amount": amount.Minor(), // Amount as string for hashing consistency
+	}
+	// Paytring refunds in the currency of the order. Sending the currency
+	// lets it reject a mismatch the order guard could not check.
+	if amount.Currency != "" {
+		requestPayload["currency"] = strings.ToUpper(amount.Currency)
 	}
 
 	hashedPayload := c.MakeHash(requestPayload) // MakeHash will add the "hash" field
 
-	body, err := json.Marshal(hashedPayload)
+	body, response, err := c.post(ctx, "PartialRefund", "v2/order/refund/partial", c.MakeAuthHeader(), hashedPayload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for PartialRefund: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.http.R().
-		SetHeaders(c.MakeAuthHeader()).
-		SetBody(body).
-		Post(c.ApiUrl + "v2/order/refund/partial")
-
-	if err != nil {
-		return nil, fmt.Errorf("PartialRefund request failed: %w", err)
+	var refund Refund
+	if err := decodeResponse(body, "refund", response, &refund); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for PartialRefund: %w", err)
 	}
 
-	var bodyMap map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &bodyMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body for PartialRefund: %w", err)
-	}
+	c.guard.observeRefund(orderID, &refund)
 
-	return c.HandleResponse(bodyMap)
+	return &refund, nil
 }
 
-func (c *Api) FetchRefundAttempts(orderID string) (map[string]interface{}, error) {
+func (c *Api) FetchRefundAttempts(orderID string) (*RefundAttempts, error) {
+	return c.FetchRefundAttemptsCtx(context.Background(), orderID)
+}
+
+func (c *Api) FetchRefundAttemptsCtx(ctx context.Context, orderID string) (*RefundAttempts, error) {
 	requestBody := map[string]interface{}{
 		"key":      c.ApiKey,
 		"order_id": orderID,
 		"hash":     "null",
 	}
 
-	body, err := json.Marshal(requestBody)
+	body, response, err := c.post(ctx, "FetchRefundAttempts", "v2/order/refund/attempts", c.MakeAuthHeader(), requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for FetchRefundAttempts: %w", err)
+		return nil, err
 	}
 
-	resp, err := c.http.R().
-		SetHeaders(c.MakeAuthHeader()).
-		SetBody(body).
-		Post(c.ApiUrl + "v2/order/refund/attempts")
-
-	if err != nil {
-		return nil, fmt.Errorf("FetchRefundAttempts request failed: %w", err)
+	var attempts RefundAttempts
+	if err := decodeResponse(body, "", response, &attempts); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for FetchRefundAttempts: %w", err)
 	}
 
-	var bodyMap map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &bodyMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body for FetchRefundAttempts: %w", err)
-	}
+	return &attempts, nil
+}
 
-	return c.HandleResponse(bodyMap)
+func (c *Api) FetchRefund(refundID string) (*Refund, error) {
+	return c.FetchRefundCtx(context.Background(), refundID)
 }
 
-func (c *Api) FetchRefund(refundID string) (map[string]interface{}, error) {
+func (c *Api) FetchRefundCtx(ctx context.Context, refundID string) (*Refund, error) {
 	requestBody := map[string]interface{}{
 		"key":  c.ApiKey,
 		"id":   refundID,
 		"hash": "null",
 	}
 
-	body, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for FetchRefund: %w", err)
-	}
-
-	resp, err := c.http.R().
-		SetHeaders(c.MakeAuthHeader()).
-		SetBody(body).
-		Post(c.ApiUrl + "v2/order/refund/fetch")
-
+	body, response, err := c.post(ctx, "FetchRefund", "v2/order/refund/fetch", c.MakeAuthHeader(), requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("FetchRefund request failed: %w", err)
+		return nil, err
 	}
 
-	var bodyMap map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &bodyMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response body for FetchRefund: %w", err)
+	var refund Refund
+	if err := decodeResponse(body, "refund", response, &refund); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for FetchRefund: %w", err)
 	}
 
-	return c.HandleResponse(bodyMap)
+	return &refund, nil
 }
@@ -0,0 +1,142 @@
+package paytring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// post sends requestBody to endpoint and returns the raw response body along
+// with its decoded form once HandleResponse has accepted it. name is the
+// public method name and is only used to annotate errors. Every attempt
+// passes through the client's middleware and is logged to its Logger, if
+// any. Failed attempts are retried according to c.RetryPolicy and the whole
+// call is reported to the client's Instrumentation.
+//
+// If ctx is cancelled or its deadline passes, ctx.Err() is returned as is so
+// callers can compare it against context.Canceled and
+// context.DeadlineExceeded.
+func (c *Api) post(ctx context.Context, name string, endpoint string, headers map[string]string, requestBody map[string]interface{}) ([]byte, map[string]interface{}, error) {
+
+	// Request headers win over the custom ones, so SetCustomHeaders cannot
+	// replace the SDK's authentication by accident, whatever the case of
+	// the header names.
+	headers = MergeMaps(canonicalHeaders(c.CustomHeaders), canonicalHeaders(headers))
+	if key := idempotencyKey(ctx); key != "" {
+		headers["Idempotency-Key"] = key
+	}
+
+	hash, _ := requestBody["hash"].(string)
+	signed := hash != "" && hash == c.ComputeHash(requestBody)
+
+	ctx, end := c.startCall(ctx, name, endpoint, requestBody)
+
+	rt := c.roundTrip()
+	retry := canRetry(ctx, endpoint)
+	for attempt := 1; ; attempt++ {
+		call := &Call{Method: name, Endpoint: endpoint, Attempt: attempt, Headers: MergeMaps(nil, headers), Body: requestBody, signed: signed}
+		reply, err := rt(ctx, call)
+		if reply == nil {
+			reply = &Reply{}
+		}
+		if err == nil || !retry || attempt >= c.RetryPolicy.MaxAttempts || !c.RetryPolicy.shouldRetry(err) {
+			orderId, _ := reply.Response["order_id"].(string)
+			end(CallResult{StatusCode: reply.StatusCode, OrderId: orderId, Attempts: attempt, Err: err})
+			if err != nil {
+				return nil, nil, err
+			}
+			return reply.Body, reply.Response, nil
+		}
+		if err := sleepContext(ctx, c.RetryPolicy.backoff(attempt)); err != nil {
+			end(CallResult{StatusCode: reply.StatusCode, Attempts: attempt, Err: err})
+			return nil, nil, err
+		}
+	}
+}
+
+// canonicalHeaders returns headers with their names in canonical form, the
+// way net/http sends them.
+func canonicalHeaders(headers map[string]string) map[string]string {
+	canonical := make(map[string]string, len(headers))
+	for key, value := range headers {
+		canonical[http.CanonicalHeaderKey(key)] = value
+	}
+	return canonical
+}
+
+// send performs a single attempt of a request prepared by post and logs its
+// outcome. status is the HTTP status of the response, or 0 when none was
+// received; it is filled in by the deferred func, so the returns below
+// leave it at 0.
+func (c *Api) send(ctx context.Context, name string, endpoint string, attempt int, headers map[string]string, body []byte) (status int, respBody []byte, response map[string]interface{}, err error) {
+
+	start := time.Now()
+	var resp *resty.Response
+	defer func() {
+		id := ""
+		if resp != nil && resp.RawResponse != nil {
+			status, id = resp.StatusCode(), resp.Header().Get("X-Request-Id")
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+			id = apiErr.RequestID
+		}
+		c.logResponse(ctx, name, endpoint, attempt, status, id, time.Since(start), err)
+	}()
+
+	resp, err = c.http.R().
+		SetContext(ctx).
+		SetHeaders(headers).
+		SetBody(body).
+		Post(c.ApiUrl + endpoint)
+
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, nil, nil, ctxErr
+		}
+		return 0, nil, nil, fmt.Errorf("%s request failed: %w", name, err)
+	}
+
+	var bodyMap map[string]interface{}
+	if err := json.Unmarshal(resp.Body(), &bodyMap); err != nil {
+		if resp.IsError() {
+			return 0, nil, nil, &APIError{
+				StatusCode: resp.StatusCode(),
+				Message:    fmt.Sprintf("%s failed with HTTP status %d", name, resp.StatusCode()),
+				RequestID:  requestID(resp, nil),
+				Body:       resp.Body(),
+			}
+		}
+		return 0, nil, nil, fmt.Errorf("failed to unmarshal response body for %s: %w", name, err)
+	}
+
+	response, err = c.HandleResponse(bodyMap)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.StatusCode = resp.StatusCode()
+			apiErr.RequestID = requestID(resp, bodyMap)
+			apiErr.Body = resp.Body()
+		}
+		return 0, nil, nil, err
+	}
+
+	return 0, resp.Body(), response, nil
+}
+
+// requestID returns Paytring's identifier for the request, preferring the
+// response header over the body.
+func requestID(resp *resty.Response, bodyMap map[string]interface{}) string {
+	if id := resp.Header().Get("X-Request-Id"); id != "" {
+		return id
+	}
+	if id, ok := bodyMap["request_id"].(string); ok {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package paytring
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextDeadlineIsReturnedUnchanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret)
+	client.ApiUrl = server.URL + "/"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	resp, err := client.FetchOrderCtx(ctx, "771606428862383868", "normal")
+	assert.Nil(t, resp)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret)
+	client.ApiUrl = server.URL + "/"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := client.RefundOrderCtx(ctx, "TEST_ORDER_ID_REFUND")
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,150 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Read-only endpoints (fetches, VPA, BIN and currency lookups) are retried
+// whenever the policy allows. Calls that change state, such as CreateOrder,
+// RefundOrder or CaptureOrder, are only retried when the context carries an
+// idempotency key, see ContextWithIdempotencyKey.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry. It doubles with every
+	// further attempt up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter is the fraction, between 0 and 1, of each backoff that is
+	// randomised so that clients do not retry in lockstep.
+	Jitter float64
+	// RetryableStatusCodes lists the HTTP statuses worth retrying.
+	RetryableStatusCodes []int
+	// RetryableError reports whether a transport level error, such as a
+	// connection reset, is worth retrying. Nil means none are.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy is used by NewClient unless WithRetryPolicy is given.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 200 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+		Jitter:      0.5,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableError: IsNetworkError,
+	}
+}
+
+// IsNetworkError reports whether err was raised while talking to Paytring,
+// before any response was received.
+func IsNetworkError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+func (p RetryPolicy) shouldRetry(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range p.RetryableStatusCodes {
+			if apiErr.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+	return p.RetryableError != nil && p.RetryableError(err)
+}
+
+// backoff returns how long to wait after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Api) {
+		c.RetryPolicy = policy
+	}
+}
+
+type idempotencyKeyContextKey struct{}
+
+// ContextWithIdempotencyKey returns a context that sends key as the
+// Idempotency-Key header and allows calls that change state to be retried.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+type noRetryContextKey struct{}
+
+// withoutRetries returns a context in which post makes a single attempt,
+// for callers that run their own retry loop.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryContextKey{}, true)
+}
+
+// safeEndpoints are read-only and can always be retried.
+var safeEndpoints = map[string]bool{
+	"v2/order/fetch":             true,
+	"v2/order/fetch/receipt":     true,
+	"v2/order/refund/fetch":      true,
+	"v2/order/refund/attempts":   true,
+	"v1/info/vpa":                true,
+	"v1/health/bin":              true,
+	"v1/currency/get":            true,
+	"v2/link/fetch":              true,
+	"v2/link/list":               true,
+	"v2/subscription/plan/fetch": true,
+	"v2/mandate/fetch":           true,
+	"v2/card/token/list":         true,
+	"v2/customer/fetch":          true,
+}
+
+func canRetry(ctx context.Context, endpoint string) bool {
+	if noRetry, _ := ctx.Value(noRetryContextKey{}).(bool); noRetry {
+		return false
+	}
+	return safeEndpoints[endpoint] || idempotencyKey(ctx) != ""
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
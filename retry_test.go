@@ -0,0 +1,104 @@
+package paytring
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fastRetries = RetryPolicy{
+	MaxAttempts:          3,
+	BaseBackoff:          time.Millisecond,
+	MaxBackoff:           5 * time.Millisecond,
+	RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	RetryableError:       IsNetworkError,
+}
+
+func flakyServer(failures int32, calls *int32, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":false,"error":{"message":"try again"}}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestRetryFetchOrder(t *testing.T) {
+	var calls int32
+	server := flakyServer(2, &calls, `{"status":true,"order":{"order_id":"1"}}`)
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+	order, err := client.FetchOrder("1", "normal")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", order.OrderId)
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := flakyServer(5, &calls, `{"status":true}`)
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+	_, err := client.ValidateCard("418730")
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestNoRetryForMutatingCalls(t *testing.T) {
+	var calls int32
+	server := flakyServer(1, &calls, `{"status":true}`)
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+	_, err := client.CaptureOrder("772677896533967081")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestRetryWithIdempotencyKey(t *testing.T) {
+	var calls int32
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":true,"refund":{"refund_id":"r1"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKey, apiSecret, WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+	ctx := ContextWithIdempotencyKey(context.Background(), "refund-TEST_ORDER_ID_REFUND")
+	refund, err := client.RefundOrderCtx(ctx, "TEST_ORDER_ID_REFUND")
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", refund.RefundId)
+	assert.Equal(t, []string{"refund-TEST_ORDER_ID_REFUND", "refund-TEST_ORDER_ID_REFUND"}, keys)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := policy.backoff(2)
+		assert.True(t, d > 100*time.Millisecond && d <= 200*time.Millisecond)
+	}
+}
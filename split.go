@@ -0,0 +1,237 @@
+package paytring
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// SplitType says how the rules of a SplitSettlement divide the order
+// amount between vendors.
+type SplitType string
+
+const (
+	// SplitFixed gives each vendor the Amount of its rule.
+	SplitFixed SplitType = "fixed"
+	// SplitPercentage gives each vendor BasisPoints hundredths of a
+	// percent of the order amount.
+	SplitPercentage SplitType = "percentage"
+)
+
+// Valid reports whether Paytring supports t.
+func (t SplitType) Valid() bool {
+	return t == SplitFixed || t == SplitPercentage
+}
+
+// SplitRule is the share of one vendor. Amount is used by SplitFixed,
+// BasisPoints by SplitPercentage: 2550 is 25.50%.
+type SplitRule struct {
+	VendorId    string
+	Amount      Money
+	BasisPoints int64
+}
+
+// SplitSettlement divides the amount of an order between vendors. Whatever
+// the rules leave over settles to the merchant.
+type SplitSettlement struct {
+	SplitType SplitType
+	SplitRule []SplitRule
+}
+
+// Validate checks s against an order of total. The returned error is a
+// *ValidationError listing every problem found.
+func (s SplitSettlement) Validate(total Money) error {
+	verr := &ValidationError{}
+	s.validate(verr, total)
+	return verr.err()
+}
+
+func (s SplitSettlement) validate(verr *ValidationError, total Money) {
+	if s.SplitType == "" && len(s.SplitRule) > 0 {
+		verr.add("split_type", "is required when split rules are given")
+	}
+	if s.SplitType != "" && !s.SplitType.Valid() {
+		verr.add("split_type", "must be %s or %s", SplitFixed, SplitPercentage)
+	}
+	if s.SplitType != "" && len(s.SplitRule) == 0 {
+		verr.add("split_settlement", "needs at least one split rule")
+	}
+
+	seen := map[string]int{}
+	var sum, points int64
+	for i, rule := range s.SplitRule {
+		field := fmt.Sprintf("split_settlement.%d", i)
+
+		if rule.VendorId == "" {
+			verr.add(field+".vendor_id", "is required")
+		} else if first, ok := seen[rule.VendorId]; ok {
+			verr.add(field+".vendor_id", "duplicates split rule %d", first)
+		} else {
+			seen[rule.VendorId] = i
+		}
+
+		switch s.SplitType {
+		case SplitFixed:
+			if rule.Amount.Currency != "" && !strings.EqualFold(rule.Amount.Currency, total.Currency) {
+				verr.add(field+".amount", "currency %s does not match order currency %s", rule.Amount.Currency, total.Currency)
+			} else if !rule.Amount.IsPositive() {
+				verr.add(field+".amount", "must be greater than zero")
+			} else {
+				sum += rule.Amount.Amount
+			}
+		case SplitPercentage:
+			if rule.BasisPoints <= 0 || rule.BasisPoints > 10000 {
+				verr.add(field+".basis_points", "must be between 1 and 10000")
+			} else {
+				points += rule.BasisPoints
+			}
+		}
+	}
+
+	if sum > total.Amount {
+		verr.add("split_settlement", "splits of %s exceed the order amount of %s", NewMoney(sum, total.Currency), total)
+	}
+	if points > 10000 {
+		verr.add("split_settlement", "splits add up to more than 100%%")
+	}
+}
+
+// Resolve returns the fixed amount each vendor receives from an order of
+// total. Percentage shares are rounded down and the leftover minor units
+// go to the vendors with the largest remainders, earlier rules first on a
+// tie, so the result never exceeds what the percentages add up to.
+func (s SplitSettlement) Resolve(total Money) ([]SplitRule, error) {
+	if err := s.Validate(total); err != nil {
+		return nil, err
+	}
+
+	rules := make([]SplitRule, len(s.SplitRule))
+	if s.SplitType == SplitFixed {
+		for i, rule := range s.SplitRule {
+			rules[i] = SplitRule{VendorId: rule.VendorId, Amount: NewMoney(rule.Amount.Amount, total.Currency)}
+		}
+		return rules, nil
+	}
+
+	// The merchant keeps whatever the percentages leave over, it takes
+	// part in the rounding as the last share.
+	weights := make([]int64, len(s.SplitRule)+1)
+	weights[len(s.SplitRule)] = 10000
+	for i, rule := range s.SplitRule {
+		weights[i] = rule.BasisPoints
+		weights[len(s.SplitRule)] -= rule.BasisPoints
+	}
+	amounts := allocate(total.Amount, weights)
+	for i, rule := range s.SplitRule {
+		rules[i] = SplitRule{VendorId: rule.VendorId, Amount: NewMoney(amounts[i], total.Currency)}
+	}
+	return rules, nil
+}
+
+// SplitRefund spreads refund back over the vendors of an order of total
+// settled with s, in proportion to what each of them received. The
+// merchant's own share takes its part of the refund too, so the returned
+// amounts add up to at most refund.
+func (s SplitSettlement) SplitRefund(total Money, refund Money) ([]SplitRule, error) {
+	if !strings.EqualFold(refund.Currency, total.Currency) {
+		return nil, fmt.Errorf("currency mismatch: %s and %s", refund.Currency, total.Currency)
+	}
+	if refund.Amount < 0 || refund.Amount > total.Amount {
+		return nil, fmt.Errorf("refund of %s is not within the order amount of %s", refund, total)
+	}
+
+	shares, err := s.Resolve(total)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := make([]int64, len(shares)+1)
+	weights[len(shares)] = total.Amount
+	for i, share := range shares {
+		weights[i] = share.Amount.Amount
+		weights[len(shares)] -= share.Amount.Amount
+	}
+	amounts := allocate(refund.Amount, weights)
+	for i := range shares {
+		shares[i].Amount = NewMoney(amounts[i], total.Currency)
+	}
+	return shares, nil
+}
+
+// SplitEvenly divides total between vendorIds. The minor units that do not
+// divide evenly go one each to the first vendors.
+func SplitEvenly(total Money, vendorIds ...string) (SplitSettlement, error) {
+	if len(vendorIds) == 0 {
+		return SplitSettlement{}, fmt.Errorf("no vendors to split %s between", total)
+	}
+	weights := make([]int64, len(vendorIds))
+	for i := range weights {
+		weights[i] = 1
+	}
+	return SplitByWeight(total, vendorIds, weights)
+}
+
+// SplitByWeight divides total between vendorIds in proportion to weights,
+// rounding like Resolve. Every weight must be positive, a vendor that
+// should get nothing has to be left out rather than given a zero weight;
+// otherwise a *ValidationError names the offending weights.
+func SplitByWeight(total Money, vendorIds []string, weights []int64) (SplitSettlement, error) {
+	if len(vendorIds) != len(weights) {
+		return SplitSettlement{}, fmt.Errorf("got %d weights for %d vendors", len(weights), len(vendorIds))
+	}
+	verr := &ValidationError{}
+	for i, weight := range weights {
+		if weight <= 0 {
+			verr.add(fmt.Sprintf("weights.%d", i), "must be greater than zero")
+		}
+	}
+	if err := verr.err(); err != nil {
+		return SplitSettlement{}, err
+	}
+	if !total.IsPositive() {
+		return SplitSettlement{}, fmt.Errorf("cannot split %s", total)
+	}
+
+	split := SplitSettlement{SplitType: SplitFixed}
+	for i, amount := range allocate(total.Amount, weights) {
+		split.SplitRule = append(split.SplitRule, SplitRule{VendorId: vendorIds[i], Amount: NewMoney(amount, total.Currency)})
+	}
+	return split, split.Validate(total)
+}
+
+// allocate divides amount in proportion to weights with the largest
+// remainder method: every share is rounded down, then the leftover units
+// go one each to the shares with the largest remainders, lower indexes
+// first on a tie.
+func allocate(amount int64, weights []int64) []int64 {
+	shares := make([]int64, len(weights))
+	remainders := make([]*big.Int, len(weights))
+
+	sum := new(big.Int)
+	for _, weight := range weights {
+		sum.Add(sum, big.NewInt(weight))
+	}
+	if sum.Sign() == 0 {
+		return shares
+	}
+
+	left := amount
+	for i, weight := range weights {
+		quo, rem := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(amount), big.NewInt(weight)), sum, new(big.Int))
+		shares[i] = quo.Int64()
+		remainders[i] = rem
+		left -= shares[i]
+	}
+
+	for ; left > 0; left-- {
+		best := -1
+		for i, rem := range remainders {
+			if weights[i] > 0 && (best < 0 || rem.Cmp(remainders[best]) > 0) {
+				best = i
+			}
+		}
+		shares[best]++
+		remainders[best] = new(big.Int).Sub(remainders[best], sum)
+	}
+	return shares
+}
@@ -0,0 +1,103 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSettlementValidate(t *testing.T) {
+	total := NewMoney(1000, "INR")
+
+	split := SplitSettlement{SplitType: SplitFixed, SplitRule: []SplitRule{
+		{VendorId: "V1", Amount: NewMoney(600, "INR")},
+		{VendorId: "V1", Amount: NewMoney(500, "INR")},
+		{Amount: NewMoney(0, "INR")},
+	}}
+
+	var verr *ValidationError
+	assert.True(t, errors.As(split.Validate(total), &verr))
+	assert.Equal(t, "duplicates split rule 0", verr.FieldError("split_settlement.1.vendor_id"))
+	assert.Equal(t, "is required", verr.FieldError("split_settlement.2.vendor_id"))
+	assert.Equal(t, "must be greater than zero", verr.FieldError("split_settlement.2.amount"))
+	assert.Equal(t, "splits of 11.00 INR exceed the order amount of 10.00 INR", verr.FieldError("split_settlement"))
+
+	split = SplitSettlement{SplitType: "equal", SplitRule: []SplitRule{{VendorId: "V1"}}}
+	assert.True(t, errors.As(split.Validate(total), &verr))
+	assert.Equal(t, "must be fixed or percentage", verr.FieldError("split_type"))
+
+	split = SplitSettlement{SplitType: SplitPercentage, SplitRule: []SplitRule{
+		{VendorId: "V1", BasisPoints: 6000},
+		{VendorId: "V2", BasisPoints: 5000},
+	}}
+	assert.True(t, errors.As(split.Validate(total), &verr))
+	assert.Equal(t, "splits add up to more than 100%", verr.FieldError("split_settlement"))
+}
+
+func TestSplitEvenly(t *testing.T) {
+	split, err := SplitEvenly(NewMoney(1000, "INR"), "V1", "V2", "V3")
+	assert.NoError(t, err)
+	assert.Equal(t, SplitFixed, split.SplitType)
+	assert.Equal(t, []SplitRule{
+		{VendorId: "V1", Amount: NewMoney(334, "INR")},
+		{VendorId: "V2", Amount: NewMoney(333, "INR")},
+		{VendorId: "V3", Amount: NewMoney(333, "INR")},
+	}, split.SplitRule)
+
+	_, err = SplitEvenly(NewMoney(1000, "INR"))
+	assert.Error(t, err)
+}
+
+func TestSplitByWeightRejectsNonPositiveWeights(t *testing.T) {
+	_, err := SplitByWeight(NewMoney(1000, "INR"), []string{"V1", "V2", "V3"}, []int64{2, 0, -1})
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "must be greater than zero", verr.FieldError("weights.1"))
+	assert.Equal(t, "must be greater than zero", verr.FieldError("weights.2"))
+	assert.Len(t, verr.Fields, 2)
+
+	split, err := SplitByWeight(NewMoney(1000, "INR"), []string{"V1", "V2"}, []int64{3, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(750, "INR"), split.SplitRule[0].Amount)
+}
+
+func TestSplitResolvePercentage(t *testing.T) {
+	split := SplitSettlement{SplitType: SplitPercentage, SplitRule: []SplitRule{
+		{VendorId: "V1", BasisPoints: 3333},
+		{VendorId: "V2", BasisPoints: 3333},
+		{VendorId: "V3", BasisPoints: 3334},
+	}}
+
+	rules, err := split.Resolve(NewMoney(100, "INR"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(33), rules[0].Amount.Amount)
+	assert.Equal(t, int64(33), rules[1].Amount.Amount)
+	assert.Equal(t, int64(34), rules[2].Amount.Amount)
+}
+
+func TestSplitRefund(t *testing.T) {
+	total := NewMoney(1000, "INR")
+	split := SplitSettlement{SplitType: SplitFixed, SplitRule: []SplitRule{
+		{VendorId: "V1", Amount: NewMoney(500, "INR")},
+		{VendorId: "V2", Amount: NewMoney(300, "INR")},
+	}}
+
+	rules, err := split.SplitRefund(total, NewMoney(333, "INR"))
+	assert.NoError(t, err)
+	assert.Equal(t, []SplitRule{
+		{VendorId: "V1", Amount: NewMoney(166, "INR")},
+		{VendorId: "V2", Amount: NewMoney(100, "INR")},
+	}, rules)
+
+	rules, err = split.SplitRefund(total, total)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(500, "INR"), rules[0].Amount)
+	assert.Equal(t, NewMoney(300, "INR"), rules[1].Amount)
+
+	_, err = split.SplitRefund(total, NewMoney(1001, "INR"))
+	assert.Error(t, err)
+	_, err = split.SplitRefund(total, NewMoney(100, "USD"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,531 @@
+package paytring
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlanInterval is how often a plan or mandate is billed.
+type PlanInterval string
+
+const (
+	PlanDaily   PlanInterval = "day"
+	PlanWeekly  PlanInterval = "week"
+	PlanMonthly PlanInterval = "month"
+	PlanYearly  PlanInterval = "year"
+)
+
+// Valid reports whether Paytring supports i.
+func (i PlanInterval) Valid() bool {
+	switch i {
+	case PlanDaily, PlanWeekly, PlanMonthly, PlanYearly:
+		return true
+	}
+	return false
+}
+
+// PlanRequest describes a billing plan to create with CreatePlan. The plan
+// bills Amount every IntervalCount Intervals, e.g. every 1 month.
+type PlanRequest struct {
+	Name          string
+	Amount        Money
+	Interval      PlanInterval
+	IntervalCount int
+	Description   string
+	Notes         *Notes
+}
+
+// Validate checks the whole request without sending it. The returned error
+// is a *ValidationError listing every problem found.
+func (r *PlanRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if r.Name == "" {
+		verr.add("name", "is required")
+	}
+	if !r.Amount.IsPositive() {
+		verr.add("amount", "must be greater than zero")
+	}
+	if _, err := CurrencyExponent(currencyOrDefault(r.Amount.Currency)); err != nil {
+		verr.add("currency", "must be a three letter ISO 4217 code")
+	}
+	if !r.Interval.Valid() {
+		verr.add("interval", "must be %s, %s, %s or %s", PlanDaily, PlanWeekly, PlanMonthly, PlanYearly)
+	}
+	if r.IntervalCount < 0 {
+		verr.add("interval_count", "must not be negative")
+	}
+
+	return verr.err()
+}
+
+// Plan is a billing plan as returned by CreatePlan and FetchPlan.
+type Plan struct {
+	PlanId        string       `json:"plan_id"`
+	Name          string       `json:"name"`
+	Amount        Amount       `json:"amount"`
+	Currency      string       `json:"currency"`
+	Interval      PlanInterval `json:"interval"`
+	IntervalCount int          `json:"interval_count"`
+	Description   string       `json:"description"`
+	Notes         Notes        `json:"notes"`
+	Response
+}
+
+// MandateMethod is how the customer authorises recurring debits.
+type MandateMethod string
+
+const (
+	// MandateUPI is UPI AutoPay, approved in the customer's UPI app.
+	MandateUPI MandateMethod = "upi"
+	// MandateCard is a card e-mandate, approved on the hosted page.
+	MandateCard MandateMethod = "card"
+	// MandateENach is an e-NACH mandate on a bank account.
+	MandateENach MandateMethod = "enach"
+)
+
+// MandateStatus is the state of a mandate.
+type MandateStatus string
+
+const (
+	// MandateCreated mandates wait for the customer's approval.
+	MandateCreated   MandateStatus = "created"
+	MandateActive    MandateStatus = "active"
+	MandatePaused    MandateStatus = "paused"
+	MandateCancelled MandateStatus = "cancelled"
+	MandateExpired   MandateStatus = "expired"
+	MandateFailed    MandateStatus = "failed"
+)
+
+// Terminal reports whether s is final.
+func (s MandateStatus) Terminal() bool {
+	switch s {
+	case MandateCancelled, MandateExpired, MandateFailed:
+		return true
+	}
+	return false
+}
+
+// BankAccount is the account an e-NACH mandate debits.
+type BankAccount struct {
+	AccountNumber string `json:"account_number"`
+	Ifsc          string `json:"ifsc"`
+	Name          string `json:"name"`
+	// AccountType is "savings" or "current".
+	AccountType string `json:"account_type"`
+}
+
+var ifscPattern = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+
+// MandateRequest describes a mandate to register with CreateMandate. The
+// mandate either follows a plan or bills every Frequency.
+type MandateRequest struct {
+	ReceiptId string
+	Method    MandateMethod
+	// MaxAmount caps every single debit made against the mandate.
+	MaxAmount Money
+	PlanId    string
+	Frequency PlanInterval
+	StartAt   time.Time
+	EndAt     time.Time
+	Customer  Customer
+	// Vpa is required for MandateUPI.
+	Vpa string
+	// BankAccount is required for MandateENach.
+	BankAccount *BankAccount
+	CallbackUrl string
+	Notes       *Notes
+}
+
+// Validate checks the whole request without sending it. The returned error
+// is a *ValidationError listing every problem found.
+func (r *MandateRequest) Validate() error {
+	verr := &ValidationError{}
+
+	if r.ReceiptId == "" {
+		verr.add("receipt_id", "is required")
+	}
+	if !r.MaxAmount.IsPositive() {
+		verr.add("max_amount", "must be greater than zero")
+	}
+	if _, err := CurrencyExponent(currencyOrDefault(r.MaxAmount.Currency)); err != nil {
+		verr.add("currency", "must be a three letter ISO 4217 code")
+	}
+
+	switch {
+	case r.PlanId == "" && r.Frequency == "":
+		verr.add("frequency", "is required without a plan_id")
+	case r.PlanId != "" && r.Frequency != "":
+		verr.add("frequency", "cannot be combined with plan_id")
+	case r.Frequency != "" && !r.Frequency.Valid():
+		verr.add("frequency", "must be %s, %s, %s or %s", PlanDaily, PlanWeekly, PlanMonthly, PlanYearly)
+	}
+
+	if !r.StartAt.IsZero() && !r.EndAt.IsZero() && !r.EndAt.After(r.StartAt) {
+		verr.add("end_at", "must be after start_at")
+	}
+	if r.CallbackUrl != "" && !validCallbackURL(r.CallbackUrl) {
+		verr.add("callback_url", "must be an absolute http or https URL")
+	}
+
+	switch r.Method {
+	case MandateUPI:
+		if r.Vpa == "" {
+			verr.add("vpa", "is required for UPI AutoPay")
+		} else if !strings.Contains(r.Vpa, "@") {
+			verr.add("vpa", "must look like name@bank")
+		}
+	case MandateENach:
+		if r.BankAccount == nil {
+			verr.add("bank_account", "is required for e-NACH")
+			break
+		}
+		if r.BankAccount.AccountNumber == "" {
+			verr.add("bank_account.account_number", "is required")
+		}
+		if !ifscPattern.MatchString(r.BankAccount.Ifsc) {
+			verr.add("bank_account.ifsc", "must be an 11 character IFSC")
+		}
+	case MandateCard:
+	default:
+		verr.add("method", "must be %s, %s or %s", MandateUPI, MandateCard, MandateENach)
+	}
+
+	return verr.err()
+}
+
+// body builds the create mandate payload for key.
+func (r *MandateRequest) body(key string) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"key":        key,
+		"receipt_id": r.ReceiptId,
+		"method":     string(r.Method),
+		"max_amount": r.MaxAmount.Minor(),
+		"currency":   currencyOrDefault(r.MaxAmount.Currency),
+		"cname":      r.Customer.Name,
+		"phone":      r.Customer.Phone,
+		"email":      r.Customer.Email,
+		"hash":       "none",
+	}
+
+	addToMapIfNotBlank(requestBody, "plan_id", r.PlanId)
+	addToMapIfNotBlank(requestBody, "frequency", string(r.Frequency))
+	addToMapIfNotBlank(requestBody, "callback_url", r.CallbackUrl)
+	addToMapIfNotBlank(requestBody, "vpa", r.Vpa)
+	if !r.StartAt.IsZero() {
+		requestBody["start_at"] = r.StartAt.UTC().Format(time.RFC3339)
+	}
+	if !r.EndAt.IsZero() {
+		requestBody["end_at"] = r.EndAt.UTC().Format(time.RFC3339)
+	}
+
+	if account := r.BankAccount; account != nil {
+		accountMap := make(map[string]interface{})
+		addToMapIfNotBlank(accountMap, "account_number", account.AccountNumber)
+		addToMapIfNotBlank(accountMap, "ifsc", account.Ifsc)
+		addToMapIfNotBlank(accountMap, "name", account.Name)
+		addToMapIfNotBlank(accountMap, "account_type", account.AccountType)
+		requestBody["bank_account"] = accountMap
+	}
+
+	if notes := notesMap(r.Notes); len(notes) > 0 {
+		requestBody["notes"] = notes
+	}
+
+	return requestBody
+}
+
+// Mandate is a mandate as returned by CreateMandate and friends. Until the
+// customer approves it, Url is the page or UPI intent to send them to.
+type Mandate struct {
+	MandateId string        `json:"mandate_id"`
+	ReceiptId string        `json:"receipt_id"`
+	Method    MandateMethod `json:"method"`
+	Status    MandateStatus `json:"mandate_status"`
+	MaxAmount Amount        `json:"max_amount"`
+	Currency  string        `json:"currency"`
+	PlanId    string        `json:"plan_id"`
+	Frequency PlanInterval  `json:"frequency"`
+	Url       string        `json:"url"`
+	StartAt   string        `json:"start_at"`
+	EndAt     string        `json:"end_at"`
+	Customer  Customer      `json:"customer"`
+	Response
+}
+
+// CanDebit, CanPause, CanResume and CanCancel report whether the mandate's
+// status allows the matching call.
+func (m *Mandate) CanDebit() bool  { return m.Status == MandateActive }
+func (m *Mandate) CanPause() bool  { return m.Status == MandateActive }
+func (m *Mandate) CanResume() bool { return m.Status == MandatePaused }
+func (m *Mandate) CanCancel() bool { return !m.Status.Terminal() }
+
+// PreDebitNotice is returned by NotifyPreDebit. Its NoticeId is passed to
+// ExecuteDebit.
+type PreDebitNotice struct {
+	NoticeId  string `json:"notice_id"`
+	MandateId string `json:"mandate_id"`
+	Amount    Amount `json:"amount"`
+	Currency  string `json:"currency"`
+	DebitAt   string `json:"debit_at"`
+	Response
+}
+
+// PreDebitNoticePeriod is how long before a debit the customer must be
+// notified, as required by RBI for recurring payments.
+const PreDebitNoticePeriod = 24 * time.Hour
+
+// DebitRequest describes a recurring debit to make with ExecuteDebit.
+type DebitRequest struct {
+	MandateId string
+	// ReceiptId identifies the order created for the debit.
+	ReceiptId string
+	Amount    Money
+	// NoticeId is the pre-debit notification sent for this debit.
+	NoticeId string
+}
+
+// MandateDebit is returned by ExecuteDebit. The debit is an order: follow
+// it with FetchOrder or WaitForOrder.
+type MandateDebit struct {
+	OrderId   string      `json:"order_id"`
+	MandateId string      `json:"mandate_id"`
+	Amount    Amount      `json:"amount"`
+	Currency  string      `json:"currency"`
+	Status    OrderStatus `json:"order_status"`
+	Response
+}
+
+func (c *Api) CreatePlan(req *PlanRequest) (*Plan, error) {
+	return c.CreatePlanCtx(context.Background(), req)
+}
+
+// CreatePlanCtx validates req and creates the plan.
+func (c *Api) CreatePlanCtx(ctx context.Context, req *PlanRequest) (*Plan, error) {
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	intervalCount := req.IntervalCount
+	if intervalCount == 0 {
+		intervalCount = 1
+	}
+
+	requestBody := map[string]interface{}{
+		"key":            c.ApiKey,
+		"name":           req.Name,
+		"amount":         req.Amount.Minor(),
+		"currency":       currencyOrDefault(req.Amount.Currency),
+		"interval":       string(req.Interval),
+		"interval_count": strconv.Itoa(intervalCount),
+		"hash":           "none",
+	}
+	addToMapIfNotBlank(requestBody, "description", req.Description)
+	if notes := notesMap(req.Notes); len(notes) > 0 {
+		requestBody["notes"] = notes
+	}
+
+	return c.planCall(ctx, "CreatePlan", "v2/subscription/plan/create", requestBody)
+}
+
+func (c *Api) FetchPlan(planId string) (*Plan, error) {
+	return c.FetchPlanCtx(context.Background(), planId)
+}
+
+func (c *Api) FetchPlanCtx(ctx context.Context, planId string) (*Plan, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   planId,
+		"hash": "none",
+	}
+
+	return c.planCall(ctx, "FetchPlan", "v2/subscription/plan/fetch", requestBody)
+}
+
+func (c *Api) CreateMandate(req *MandateRequest) (*Mandate, error) {
+	return c.CreateMandateCtx(context.Background(), req)
+}
+
+// CreateMandateCtx validates req and registers the mandate. It stays
+// MandateCreated until the customer approves it at Mandate.Url.
+func (c *Api) CreateMandateCtx(ctx context.Context, req *MandateRequest) (*Mandate, error) {
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c.mandateCall(ctx, "CreateMandate", "v2/mandate/create", req.body(c.ApiKey))
+}
+
+func (c *Api) FetchMandate(mandateId string) (*Mandate, error) {
+	return c.FetchMandateCtx(context.Background(), mandateId)
+}
+
+func (c *Api) FetchMandateCtx(ctx context.Context, mandateId string) (*Mandate, error) {
+	return c.mandateAction(ctx, "FetchMandate", "v2/mandate/fetch", mandateId)
+}
+
+func (c *Api) NotifyPreDebit(mandateId string, amount Money, debitAt time.Time) (*PreDebitNotice, error) {
+	return c.NotifyPreDebitCtx(context.Background(), mandateId, amount, debitAt)
+}
+
+// NotifyPreDebitCtx tells the customer that amount will be debited at
+// debitAt, which must be at least PreDebitNoticePeriod away.
+func (c *Api) NotifyPreDebitCtx(ctx context.Context, mandateId string, amount Money, debitAt time.Time) (*PreDebitNotice, error) {
+
+	verr := &ValidationError{}
+	if mandateId == "" {
+		verr.add("mandate_id", "is required")
+	}
+	if !amount.IsPositive() {
+		verr.add("amount", "must be greater than zero")
+	}
+	if debitAt.Before(c.now().Add(PreDebitNoticePeriod)) {
+		verr.add("debit_at", "must be at least %s away", PreDebitNoticePeriod)
+	}
+	if err := verr.err(); err != nil {
+		return nil, err
+	}
+
+	requestBody := map[string]interface{}{
+		"key":        c.ApiKey,
+		"mandate_id": mandateId,
+		"amount":     amount.Minor(),
+		"currency":   currencyOrDefault(amount.Currency),
+		"debit_at":   debitAt.UTC().Format(time.RFC3339),
+	}
+
+	body, response, err := c.post(ctx, "NotifyPreDebit", "v2/mandate/notify", c.MakeAuthHeader(), c.MakeHash(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var notice PreDebitNotice
+	if err := decodeResponse(body, "notice", response, &notice); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for NotifyPreDebit: %w", err)
+	}
+
+	return &notice, nil
+}
+
+func (c *Api) ExecuteDebit(req DebitRequest) (*MandateDebit, error) {
+	return c.ExecuteDebitCtx(context.Background(), req)
+}
+
+// ExecuteDebitCtx charges req.Amount against an active mandate. The
+// customer must have been notified with NotifyPreDebit first.
+func (c *Api) ExecuteDebitCtx(ctx context.Context, req DebitRequest) (*MandateDebit, error) {
+
+	verr := &ValidationError{}
+	if req.MandateId == "" {
+		verr.add("mandate_id", "is required")
+	}
+	if req.ReceiptId == "" {
+		verr.add("receipt_id", "is required")
+	}
+	if req.NoticeId == "" {
+		verr.add("notice_id", "is required")
+	}
+	if !req.Amount.IsPositive() {
+		verr.add("amount", "must be greater than zero")
+	}
+	if err := verr.err(); err != nil {
+		return nil, err
+	}
+
+	requestBody := map[string]interface{}{
+		"key":        c.ApiKey,
+		"mandate_id": req.MandateId,
+		"receipt_id": req.ReceiptId,
+		"notice_id":  req.NoticeId,
+		"amount":     req.Amount.Minor(),
+		"currency":   currencyOrDefault(req.Amount.Currency),
+	}
+
+	body, response, err := c.post(ctx, "ExecuteDebit", "v2/mandate/debit", c.MakeAuthHeader(), c.MakeHash(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var debit MandateDebit
+	if err := decodeResponse(body, "debit", response, &debit); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for ExecuteDebit: %w", err)
+	}
+
+	return &debit, nil
+}
+
+func (c *Api) PauseMandate(mandateId string) (*Mandate, error) {
+	return c.PauseMandateCtx(context.Background(), mandateId)
+}
+
+// PauseMandateCtx stops debits against an active mandate until
+// ResumeMandate.
+func (c *Api) PauseMandateCtx(ctx context.Context, mandateId string) (*Mandate, error) {
+	return c.mandateAction(ctx, "PauseMandate", "v2/mandate/pause", mandateId)
+}
+
+func (c *Api) ResumeMandate(mandateId string) (*Mandate, error) {
+	return c.ResumeMandateCtx(context.Background(), mandateId)
+}
+
+func (c *Api) ResumeMandateCtx(ctx context.Context, mandateId string) (*Mandate, error) {
+	return c.mandateAction(ctx, "ResumeMandate", "v2/mandate/resume", mandateId)
+}
+
+func (c *Api) CancelMandate(mandateId string) (*Mandate, error) {
+	return c.CancelMandateCtx(context.Background(), mandateId)
+}
+
+// CancelMandateCtx revokes the mandate for good.
+func (c *Api) CancelMandateCtx(ctx context.Context, mandateId string) (*Mandate, error) {
+	return c.mandateAction(ctx, "CancelMandate", "v2/mandate/cancel", mandateId)
+}
+
+// mandateAction posts a request naming just mandateId to endpoint.
+func (c *Api) mandateAction(ctx context.Context, name string, endpoint string, mandateId string) (*Mandate, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   mandateId,
+		"hash": "none",
+	}
+
+	return c.mandateCall(ctx, name, endpoint, requestBody)
+}
+
+func (c *Api) mandateCall(ctx context.Context, name string, endpoint string, requestBody map[string]interface{}) (*Mandate, error) {
+
+	body, response, err := c.post(ctx, name, endpoint, c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var mandate Mandate
+	if err := decodeResponse(body, "mandate", response, &mandate); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for %s: %w", name, err)
+	}
+
+	return &mandate, nil
+}
+
+func (c *Api) planCall(ctx context.Context, name string, endpoint string, requestBody map[string]interface{}) (*Plan, error) {
+
+	body, response, err := c.post(ctx, name, endpoint, c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	if err := decodeResponse(body, "plan", response, &plan); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for %s: %w", name, err)
+	}
+
+	return &plan, nil
+}
@@ -0,0 +1,117 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMandateRequestValidate(t *testing.T) {
+	req := &MandateRequest{
+		Method:    MandateUPI,
+		MaxAmount: NewMoney(0, "INR"),
+		PlanId:    "P1",
+		Frequency: PlanMonthly,
+		StartAt:   time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:     time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Vpa:       "johndoe",
+	}
+
+	var verr *ValidationError
+	assert.True(t, errors.As(req.Validate(), &verr))
+	assert.Equal(t, "is required", verr.FieldError("receipt_id"))
+	assert.Equal(t, "must be greater than zero", verr.FieldError("max_amount"))
+	assert.Equal(t, "cannot be combined with plan_id", verr.FieldError("frequency"))
+	assert.Equal(t, "must be after start_at", verr.FieldError("end_at"))
+	assert.Equal(t, "must look like name@bank", verr.FieldError("vpa"))
+
+	req = &MandateRequest{
+		ReceiptId:   "SUB-1",
+		Method:      MandateENach,
+		MaxAmount:   NewMoney(100000, "INR"),
+		BankAccount: &BankAccount{AccountNumber: "1234567890", Ifsc: "HDFC1234567"},
+	}
+	assert.True(t, errors.As(req.Validate(), &verr))
+	assert.Equal(t, "is required without a plan_id", verr.FieldError("frequency"))
+	assert.Equal(t, "must be an 11 character IFSC", verr.FieldError("bank_account.ifsc"))
+	assert.Len(t, verr.Fields, 2)
+
+	req.Frequency = PlanMonthly
+	req.BankAccount.Ifsc = "HDFC0001234"
+	assert.NoError(t, req.Validate())
+}
+
+func TestMandateLifecycle(t *testing.T) {
+	client, server := newTestClient(t)
+
+	plan, err := client.CreatePlan(&PlanRequest{
+		Name:     "Monthly Pro",
+		Amount:   NewMoney(49900, "INR"),
+		Interval: PlanMonthly,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, plan.IntervalCount)
+
+	plan, err = client.FetchPlan(plan.PlanId)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(49900), plan.Amount)
+
+	mandate, err := client.CreateMandate(&MandateRequest{
+		ReceiptId: "SUB-1",
+		Method:    MandateUPI,
+		MaxAmount: NewMoney(100000, "INR"),
+		PlanId:    plan.PlanId,
+		Vpa:       "johndoe@okbank",
+		Customer:  Customer{Name: "John Doe", Phone: "9876543210"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, MandateCreated, mandate.Status)
+	assert.False(t, mandate.CanDebit())
+	assert.NotEmpty(t, mandate.Url)
+
+	_, err = client.NotifyPreDebit(mandate.MandateId, NewMoney(49900, "INR"), time.Now().Add(time.Hour))
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "must be at least 24h0m0s away", verr.FieldError("debit_at"))
+
+	assert.True(t, server.AuthorizeMandate(mandate.MandateId))
+	mandate, err = client.FetchMandate(mandate.MandateId)
+	assert.NoError(t, err)
+	assert.True(t, mandate.CanDebit())
+
+	notice, err := client.NotifyPreDebit(mandate.MandateId, NewMoney(49900, "INR"), time.Now().Add(25*time.Hour))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, notice.NoticeId)
+
+	debit, err := client.ExecuteDebit(DebitRequest{
+		MandateId: mandate.MandateId,
+		ReceiptId: "SUB-1-2026-04",
+		Amount:    NewMoney(49900, "INR"),
+		NoticeId:  notice.NoticeId,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, OrderCaptured, debit.Status.Normalize())
+
+	order, err := client.FetchOrder(debit.OrderId, "normal")
+	assert.NoError(t, err)
+	assert.Equal(t, "SUB-1-2026-04", order.ReceiptId)
+
+	mandate, err = client.PauseMandate(mandate.MandateId)
+	assert.NoError(t, err)
+	assert.True(t, mandate.CanResume())
+
+	_, err = client.PauseMandate(mandate.MandateId)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+
+	mandate, err = client.ResumeMandate(mandate.MandateId)
+	assert.NoError(t, err)
+	assert.Equal(t, MandateActive, mandate.Status)
+
+	mandate, err = client.CancelMandate(mandate.MandateId)
+	assert.NoError(t, err)
+	assert.True(t, mandate.Status.Terminal())
+	assert.False(t, mandate.CanCancel())
+}
@@ -0,0 +1,187 @@
+package paytring
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TokenStatus is the state of a saved card token.
+type TokenStatus string
+
+const (
+	TokenActive TokenStatus = "active"
+	// TokenSuspended tokens were suspended by the network or issuer, e.g.
+	// while the card is blocked.
+	TokenSuspended TokenStatus = "suspended"
+	TokenDeleted   TokenStatus = "deleted"
+)
+
+// TokenRequest asks for a network token for Card, saved for CustomerId.
+// RBI rules only allow saving a card the customer explicitly agreed to
+// save, so Consent must be set.
+type TokenRequest struct {
+	CustomerId string
+	Card       PaymentData
+	Consent    bool
+}
+
+// Validate checks the whole request without sending it. The returned error
+// is a *ValidationError listing every problem found.
+func (r *TokenRequest) Validate() error {
+	return r.validate(time.Now())
+}
+
+func (r *TokenRequest) validate(now time.Time) error {
+	verr := &ValidationError{}
+
+	if r.CustomerId == "" {
+		verr.add("customer_id", "is required")
+	}
+	if !r.Consent {
+		verr.add("consent", "must be given by the customer to save the card")
+	}
+
+	verr.merge(ValidateCardData(r.Card, now))
+
+	return verr.err()
+}
+
+// CardToken is a saved card as returned by CreateCardToken and
+// ListCardTokens. Only the last four digits of the card are kept.
+type CardToken struct {
+	TokenId     string      `json:"token_id"`
+	CustomerId  string      `json:"customer_id"`
+	Brand       CardBrand   `json:"brand"`
+	Last4       string      `json:"last4"`
+	ExpiryMonth string      `json:"expiry_month"`
+	ExpiryYear  string      `json:"expiry_year"`
+	HolderName  string      `json:"holder_name"`
+	Status      TokenStatus `json:"token_status"`
+	// Cryptogram authenticates a single payment with the token. A fresh
+	// one comes with every CreateCardToken and ListCardTokens.
+	Cryptogram string `json:"cryptogram"`
+	CreatedAt  string `json:"created_at"`
+	Response
+}
+
+// PaymentData returns what ProcessOrder needs to pay with t.
+func (t *CardToken) PaymentData() PaymentData {
+	return PaymentData{Token: t.TokenId, Cryptogram: t.Cryptogram, HolderName: t.HolderName}
+}
+
+// CardTokens is returned by ListCardTokens.
+type CardTokens struct {
+	Tokens []CardToken `json:"tokens"`
+	Response
+}
+
+// validateTokenData checks the token fields of data the way ProcessOrder
+// does before sending them.
+func validateTokenData(data PaymentData) error {
+	verr := &ValidationError{}
+
+	if data.Token == "" {
+		verr.add("card.token", "is required")
+	}
+	if data.Cryptogram == "" {
+		verr.add("card.cryptogram", "is required to pay with a token")
+	}
+	if data.CardNumber != "" {
+		verr.add("card.number", "cannot be combined with a token")
+	}
+
+	return verr.err()
+}
+
+func (c *Api) CreateCardToken(req *TokenRequest) (*CardToken, error) {
+	return c.CreateCardTokenCtx(context.Background(), req)
+}
+
+// CreateCardTokenCtx validates req and exchanges the card for a network
+// token. The card number is sent once and never stored by the SDK.
+func (c *Api) CreateCardTokenCtx(ctx context.Context, req *TokenRequest) (*CardToken, error) {
+
+	if err := req.validate(c.now()); err != nil {
+		return nil, err
+	}
+
+	requestBody := map[string]interface{}{
+		"key":         c.ApiKey,
+		"customer_id": req.CustomerId,
+		"consent":     "true",
+		"card": map[string]interface{}{
+			"holder_name":  req.Card.HolderName,
+			"number":       cardDigits(req.Card.CardNumber),
+			"expiry_month": req.Card.ExpiryMonth,
+			"expiry_year":  req.Card.ExpiryYear,
+			"cvv":          req.Card.Cvv,
+		},
+	}
+
+	body, response, err := c.post(ctx, "CreateCardToken", "v2/card/token/create", c.MakeAuthHeader(), c.MakeHash(requestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var token CardToken
+	if err := decodeResponse(body, "token", response, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for CreateCardToken: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (c *Api) ListCardTokens(customerId string) (*CardTokens, error) {
+	return c.ListCardTokensCtx(context.Background(), customerId)
+}
+
+// ListCardTokensCtx returns the cards saved for customerId, deleted ones
+// left out.
+func (c *Api) ListCardTokensCtx(ctx context.Context, customerId string) (*CardTokens, error) {
+
+	requestBody := map[string]interface{}{
+		"key":         c.ApiKey,
+		"customer_id": customerId,
+		"hash":        "none",
+	}
+
+	body, response, err := c.post(ctx, "ListCardTokens", "v2/card/token/list", c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens CardTokens
+	if err := decodeResponse(body, "", response, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for ListCardTokens: %w", err)
+	}
+
+	return &tokens, nil
+}
+
+func (c *Api) DeleteCardToken(tokenId string) (*CardToken, error) {
+	return c.DeleteCardTokenCtx(context.Background(), tokenId)
+}
+
+// DeleteCardTokenCtx removes a saved card, e.g. when the customer asks for
+// it to be forgotten.
+func (c *Api) DeleteCardTokenCtx(ctx context.Context, tokenId string) (*CardToken, error) {
+
+	requestBody := map[string]interface{}{
+		"key":  c.ApiKey,
+		"id":   tokenId,
+		"hash": "none",
+	}
+
+	body, response, err := c.post(ctx, "DeleteCardToken", "v2/card/token/delete", c.MakeAuthHeader(), requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var token CardToken
+	if err := decodeResponse(body, "token", response, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for DeleteCardToken: %w", err)
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,70 @@
+package paytring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRequestValidate(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	req := &TokenRequest{Card: PaymentData{CardNumber: "4111111111111112", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "123"}}
+
+	var verr *ValidationError
+	assert.True(t, errors.As(req.validate(now), &verr))
+	assert.Equal(t, "is required", verr.FieldError("customer_id"))
+	assert.Equal(t, "must be given by the customer to save the card", verr.FieldError("consent"))
+	assert.Equal(t, "is not a valid card number", verr.FieldError("card.number"))
+
+	req = &TokenRequest{
+		CustomerId: "CUST-1",
+		Card:       PaymentData{CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2030", Cvv: "123"},
+		Consent:    true,
+	}
+	assert.NoError(t, req.validate(now))
+}
+
+func TestPayWithCardToken(t *testing.T) {
+	client, server := newTestClient(t)
+
+	token, err := client.CreateCardToken(&TokenRequest{
+		CustomerId: "CUST-1",
+		Card:       PaymentData{CardNumber: "4111 1111 1111 1111", ExpiryMonth: "12", ExpiryYear: "2040", Cvv: "123", HolderName: "John Doe"},
+		Consent:    true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, CardVisa, token.Brand)
+	assert.Equal(t, "1111", token.Last4)
+	assert.Equal(t, TokenActive, token.Status)
+	assert.NotEmpty(t, token.Cryptogram)
+
+	order, err := client.CreateOrder(NewMoney(1000, "INR"), "TEST_RECEIPT_TOKEN", "https://example.com/callback", Customer{})
+	assert.NoError(t, err)
+
+	_, err = client.ProcessOrder(order.OrderId, "card", "", PaymentData{Token: token.TokenId}, "")
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "is required to pay with a token", verr.FieldError("card.cryptogram"))
+
+	processed, err := client.ProcessOrder(order.OrderId, "card", "", token.PaymentData(), "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, processed.Url)
+
+	tokens, err := client.ListCardTokens("CUST-1")
+	assert.NoError(t, err)
+	assert.Len(t, tokens.Tokens, 1)
+
+	deleted, err := client.DeleteCardToken(token.TokenId)
+	assert.NoError(t, err)
+	assert.Equal(t, TokenDeleted, deleted.Status)
+
+	stored, _ := server.Token(token.TokenId)
+	assert.Equal(t, "1111", stored.Last4)
+
+	tokens, err = client.ListCardTokens("CUST-1")
+	assert.NoError(t, err)
+	assert.Empty(t, tokens.Tokens)
+}
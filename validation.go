@@ -1,80 +1,62 @@
 package paytring
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 )
 
-func (c *Api) ValidateVPA(vpa string) (map[string]interface{}, error) {
+func (c *Api) ValidateVPA(vpa string) (*VPAInfo, error) {
+	return c.ValidateVPACtx(context.Background(), vpa)
+}
+
+func (c *Api) ValidateVPACtx(ctx context.Context, vpa string) (*VPAInfo, error) {
 
 	requestBody := map[string]interface{}{
 		"key": c.ApiKey,
 		"vpa": vpa,
 	}
 
-	body, err := json.Marshal(c.MakeHash(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for ValidateVPA: %w", err)
-	}
-
-	resp, err := c.http.R().SetHeaders(c.MakeAuthHeader()).SetBody(body).Post(c.ApiUrl + "v1/info/vpa")
-
+	body, response, err := c.post(ctx, "ValidateVPA", "v1/info/vpa", c.MakeAuthHeader(), c.MakeHash(requestBody))
 	if err != nil {
-		print(err)
 		return nil, err
 	}
 
-	var bodyMap map[string]interface{}
-	err = json.Unmarshal(resp.Body(), &bodyMap)
-	if err != nil {
-		print(err)
-		return nil, err
+	var info VPAInfo
+	if err := decodeResponse(body, "", response, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for ValidateVPA: %w", err)
 	}
 
-	response, err := c.HandleResponse(bodyMap)
-
-	if err != nil {
-		return nil, err
-	}
+	return &info, nil
+}
 
-	return response, nil
+func (c *Api) ValidateCard(bin string) (*BinInfo, error) {
+	return c.ValidateCardCtx(context.Background(), bin)
 }
 
-func (c *Api) ValidateCard(bin string) (map[string]interface{}, error) {
+func (c *Api) ValidateCardCtx(ctx context.Context, bin string) (*BinInfo, error) {
 
 	requestBody := map[string]interface{}{
 		"key":      c.ApiKey,
 		"bin_code": bin,
 	}
 
-	body, err := json.Marshal(c.MakeHash(requestBody))
+	body, response, err := c.post(ctx, "ValidateCard", "v1/health/bin", c.MakeAuthHeader(), c.MakeHash(requestBody))
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for ValidateCard: %w", err)
-	}
-
-	resp, err := c.http.R().SetHeaders(c.MakeAuthHeader()).SetBody(body).Post(c.ApiUrl + "v1/health/bin")
-
-	if err != nil {
-		print(err)
 		return nil, err
 	}
 
-	var bodyMap map[string]interface{}
-	err = json.Unmarshal(resp.Body(), &bodyMap)
-	if err != nil {
-		print(err)
-		return nil, err
+	var info BinInfo
+	if err := decodeResponse(body, "", response, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for ValidateCard: %w", err)
 	}
 
-	response, err := c.HandleResponse(bodyMap)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return response, nil
+	return &info, nil
 }
-func (c *Api) CurrencyConversion(from string, to string) (map[string]interface{}, error) {
+func (c *Api) CurrencyConversion(from string, to string) (*ExchangeRate, error) {
+	return c.CurrencyConversionCtx(context.Background(), from, to)
+}
+
+func (c *Api) CurrencyConversionCtx(ctx context.Context, from string, to string) (*ExchangeRate, error) {
 
 	requestBody := map[string]interface{}{
 		"key":  c.ApiKey,
@@ -82,33 +64,15 @@ func (c *Api) CurrencyConversion(from string, to string) (map[string]interface{}
 		"to":   to,
 	}
 
-	body, err := json.Marshal(c.MakeHash(requestBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body for CurrencyConversion: %w", err)
-	}
-
-	resp, err := c.http.R().
-		SetHeaders(c.MakeAuthHeader()).
-		SetBody(body).
-		Post(c.ApiUrl + "v1/currency/get")
-
+	body, response, err := c.post(ctx, "CurrencyConversion", "v1/currency/get", c.MakeAuthHeader(), c.MakeHash(requestBody))
 	if err != nil {
-		print(err)
 		return nil, err
 	}
 
-	var bodyMap map[string]interface{}
-	err = json.Unmarshal(resp.Body(), &bodyMap)
-	if err != nil {
-		print(err)
-		return nil, err
-	}
-
-	response, err := c.HandleResponse(bodyMap)
-
-	if err != nil {
-		return nil, err
+	var rate ExchangeRate
+	if err := decodeResponse(body, "", response, &rate); err != nil {
+		return nil, fmt.Errorf("failed to decode response body for CurrencyConversion: %w", err)
 	}
 
-	return response, nil
+	return &rate, nil
 }
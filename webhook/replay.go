@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// ReplayGuard remembers payloads that were already delivered. Use a shared
+// implementation when several instances receive webhooks.
+type ReplayGuard interface {
+	// Seen records key and reports whether it was already recorded.
+	Seen(key string) bool
+	// Forget removes key so that a failed delivery can be retried.
+	Forget(key string)
+}
+
+type memoryReplayGuard struct {
+	ttl  time.Duration
+	now  func() time.Time
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewMemoryReplayGuard returns an in-process ReplayGuard that remembers keys
+// for ttl.
+func NewMemoryReplayGuard(ttl time.Duration) ReplayGuard {
+	return &memoryReplayGuard{
+		ttl:  ttl,
+		now:  time.Now,
+		seen: map[string]time.Time{},
+	}
+}
+
+func (g *memoryReplayGuard) Seen(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	for k, expiry := range g.seen {
+		if now.After(expiry) {
+			delete(g.seen, k)
+		}
+	}
+
+	if _, ok := g.seen[key]; ok {
+		return true
+	}
+	g.seen[key] = now.Add(g.ttl)
+	return false
+}
+
+func (g *memoryReplayGuard) Forget(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.seen, key)
+}
@@ -0,0 +1,373 @@
+// Package webhook receives the callbacks and webhooks Paytring posts back
+// for orders, refunds and mandates, verifies their hash and dispatches them to
+// registered handlers.
+//
+//	client := paytring.NewClient(apiKey, apiSecret)
+//	hooks := webhook.NewHandler(client)
+//	hooks.OnOrderSuccess(func(ctx context.Context, e *webhook.Event) error {
+//		return markPaid(ctx, e.ReceiptId)
+//	})
+//	http.Handle("/paytring/webhook", hooks)
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paytring "github.com/paytring/go-sdk"
+)
+
+// EventType identifies what a payload is about, e.g. "order.success".
+type EventType string
+
+const (
+	OrderSuccess    EventType = "order.success"
+	OrderFailed     EventType = "order.failed"
+	OrderPending    EventType = "order.pending"
+	RefundProcessed EventType = "refund.processed"
+	RefundFailed    EventType = "refund.failed"
+
+	MandateActivated    EventType = "mandate.activated"
+	MandatePaused       EventType = "mandate.paused"
+	MandateResumed      EventType = "mandate.resumed"
+	MandateCancelled    EventType = "mandate.cancelled"
+	MandateExpired      EventType = "mandate.expired"
+	MandateFailed       EventType = "mandate.failed"
+	MandateDebitSuccess EventType = "mandate.debit.success"
+	MandateDebitFailed  EventType = "mandate.debit.failed"
+)
+
+var (
+	ErrMissingSignature = errors.New("webhook: payload is not signed")
+	ErrInvalidSignature = errors.New("webhook: hash does not match payload")
+	ErrStale            = errors.New("webhook: payload timestamp is outside the tolerance")
+	ErrMissingTimestamp = errors.New("webhook: payload has no timestamp")
+)
+
+// Event is a verified payload. Only the top level string fields of a
+// payload are covered by its hash, so only those are exposed.
+type Event struct {
+	Type      EventType
+	OrderId   string
+	ReceiptId string
+	RefundId  string
+	MandateId string
+	Amount    paytring.Amount
+	Currency  string
+	Status    string
+	// PreviousStatus is the status a mandate moved from, when Paytring
+	// sends it.
+	PreviousStatus string
+	Hash           string
+	Timestamp      time.Time
+	// Payload holds the string fields that were posted, including the hash.
+	// Only these are covered by the hash.
+	Payload map[string]interface{}
+	// Unverified holds the other fields of JSON payloads, such as numbers
+	// and nested objects. The hash does not cover them, so they may have
+	// been altered in transit.
+	Unverified map[string]interface{}
+}
+
+// HandlerFunc handles a verified event. Returning an error makes the
+// Handler answer with 500 so that Paytring delivers the payload again.
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// Handler is an http.Handler for Paytring callbacks and webhooks. Payloads
+// may be JSON or form encoded.
+type Handler struct {
+	client *paytring.Api
+
+	// Tolerance is how old a payload may be. Payloads without a timestamp
+	// are rejected unless Tolerance is zero, which turns the check off.
+	Tolerance time.Duration
+	// Replay remembers delivered payloads, so that a redelivery is
+	// acknowledged without being dispatched again. Set it to nil to
+	// dispatch duplicates.
+	Replay ReplayGuard
+	// MaxBodyBytes limits the size of accepted payloads.
+	MaxBodyBytes int64
+
+	now      func() time.Time
+	mu       sync.RWMutex
+	handlers map[EventType][]HandlerFunc
+}
+
+// NewHandler returns a Handler verifying payloads with client's ApiSecret.
+func NewHandler(client *paytring.Api) *Handler {
+	return &Handler{
+		client:       client,
+		Tolerance:    5 * time.Minute,
+		Replay:       NewMemoryReplayGuard(24 * time.Hour),
+		MaxBodyBytes: 1 << 20,
+		now:          time.Now,
+		handlers:     map[EventType][]HandlerFunc{},
+	}
+}
+
+// On registers fn for events of type t.
+func (h *Handler) On(t EventType, fn HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[t] = append(h.handlers[t], fn)
+}
+
+func (h *Handler) OnOrderSuccess(fn HandlerFunc)    { h.On(OrderSuccess, fn) }
+func (h *Handler) OnOrderFailed(fn HandlerFunc)     { h.On(OrderFailed, fn) }
+func (h *Handler) OnOrderPending(fn HandlerFunc)    { h.On(OrderPending, fn) }
+func (h *Handler) OnRefundProcessed(fn HandlerFunc) { h.On(RefundProcessed, fn) }
+func (h *Handler) OnRefundFailed(fn HandlerFunc)    { h.On(RefundFailed, fn) }
+
+func (h *Handler) OnMandateActivated(fn HandlerFunc)    { h.On(MandateActivated, fn) }
+func (h *Handler) OnMandatePaused(fn HandlerFunc)       { h.On(MandatePaused, fn) }
+func (h *Handler) OnMandateResumed(fn HandlerFunc)      { h.On(MandateResumed, fn) }
+func (h *Handler) OnMandateCancelled(fn HandlerFunc)    { h.On(MandateCancelled, fn) }
+func (h *Handler) OnMandateExpired(fn HandlerFunc)      { h.On(MandateExpired, fn) }
+func (h *Handler) OnMandateFailed(fn HandlerFunc)       { h.On(MandateFailed, fn) }
+func (h *Handler) OnMandateDebitSuccess(fn HandlerFunc) { h.On(MandateDebitSuccess, fn) }
+func (h *Handler) OnMandateDebitFailed(fn HandlerFunc)  { h.On(MandateDebitFailed, fn) }
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	event, err := h.Parse(r)
+	switch {
+	case errors.Is(err, ErrMissingSignature), errors.Is(err, ErrInvalidSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Paytring redelivers until it gets a 2xx, e.g. when our answer to an
+	// earlier delivery was lost, so duplicates are acknowledged as well.
+	if h.Replay != nil && h.Replay.Seen(replayKey(event)) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.Dispatch(r.Context(), event); err != nil {
+		if h.Replay != nil {
+			h.Replay.Forget(replayKey(event))
+		}
+		http.Error(w, "handler failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch calls every handler registered for event.Type in order and
+// stops at the first error.
+func (h *Handler) Dispatch(ctx context.Context, event *Event) error {
+	h.mu.RLock()
+	handlers := h.handlers[event.Type]
+	h.mu.RUnlock()
+
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse reads and verifies the payload of r without dispatching it. It does
+// not consult the replay guard.
+func (h *Handler) Parse(r *http.Request) (*Event, error) {
+	payload, err := h.readPayload(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.verify(payload); err != nil {
+		return nil, err
+	}
+
+	event := newEvent(payload)
+	if h.Tolerance > 0 {
+		if event.Timestamp.IsZero() {
+			return nil, ErrMissingTimestamp
+		}
+		if age := h.now().Sub(event.Timestamp); age > h.Tolerance || age < -h.Tolerance {
+			return nil, ErrStale
+		}
+	}
+
+	return event, nil
+}
+
+func (h *Handler) readPayload(r *http.Request) (map[string]interface{}, error) {
+	if h.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, h.MaxBodyBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	payload := map[string]interface{}{}
+
+	if mediaType == "application/json" {
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&payload); err != nil {
+			return nil, fmt.Errorf("webhook: invalid JSON payload: %w", err)
+		}
+		return payload, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("webhook: invalid form payload: %w", err)
+	}
+	for key, values := range r.PostForm {
+		if len(values) > 0 {
+			payload[key] = values[0]
+		}
+	}
+	return payload, nil
+}
+
+// verify checks the hash Paytring sent against the rest of payload.
+func (h *Handler) verify(payload map[string]interface{}) error {
+	if received, _ := payload["hash"].(string); received == "" {
+		return ErrMissingSignature
+	}
+
+	ok, err := h.client.VerifyHash(payload)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func newEvent(payload map[string]interface{}) *Event {
+	str := func(keys ...string) string {
+		for _, key := range keys {
+			if s, ok := payload[key].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+
+	event := &Event{
+		OrderId:   str("order_id"),
+		ReceiptId: str("receipt_id"),
+		RefundId:  str("refund_id"),
+		MandateId: str("mandate_id"),
+		Currency:  str("currency"),
+		Hash:      str("hash"),
+		Payload:   map[string]interface{}{},
+	}
+	for key, value := range payload {
+		if _, ok := value.(string); ok {
+			event.Payload[key] = value
+			continue
+		}
+		if event.Unverified == nil {
+			event.Unverified = map[string]interface{}{}
+		}
+		event.Unverified[key] = value
+	}
+
+	if amount := str("amount"); amount != "" {
+		_ = event.Amount.UnmarshalJSON([]byte(amount))
+	}
+	// JSON payloads may send the timestamp as a number.
+	timestamp := str("timestamp")
+	if n, ok := payload["timestamp"].(json.Number); ok {
+		timestamp = n.String()
+	}
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		event.Timestamp = time.Unix(ts, 0)
+	}
+
+	switch {
+	case event.RefundId != "":
+		event.Status = strings.ToLower(str("refund_status", "status"))
+	case event.MandateId != "" && event.OrderId == "":
+		event.Status = strings.ToLower(str("mandate_status", "status"))
+		event.PreviousStatus = strings.ToLower(str("previous_mandate_status", "previous_status"))
+	default:
+		event.Status = strings.ToLower(str("order_status", "status"))
+	}
+
+	if t := str("event"); t != "" {
+		event.Type = EventType(t)
+	} else if event.MandateId != "" && event.RefundId == "" {
+		event.Type = mandateEventType(event.OrderId != "", event.Status, event.PreviousStatus)
+	} else {
+		event.Type = eventType(event.RefundId != "", event.Status)
+	}
+
+	return event
+}
+
+func eventType(refund bool, status string) EventType {
+	if refund {
+		switch status {
+		case "success", "processed", "completed":
+			return RefundProcessed
+		case "failed":
+			return RefundFailed
+		}
+		return EventType("refund." + status)
+	}
+	switch status {
+	case "success", "captured":
+		return OrderSuccess
+	case "failed":
+		return OrderFailed
+	case "pending":
+		return OrderPending
+	}
+	return EventType("order." + status)
+}
+
+// mandateEventType derives the type of a mandate payload that does not
+// name one. Payloads with an order_id are about a recurring debit. A
+// mandate becoming active again after a pause was resumed, not activated.
+func mandateEventType(debit bool, status, previous string) EventType {
+	if debit {
+		switch status {
+		case "success", "captured":
+			return MandateDebitSuccess
+		case "failed":
+			return MandateDebitFailed
+		}
+		return EventType("mandate.debit." + status)
+	}
+	switch status {
+	case "active":
+		if previous == "paused" {
+			return MandateResumed
+		}
+		return MandateActivated
+	case "paused":
+		return MandatePaused
+	case "cancelled":
+		return MandateCancelled
+	case "expired":
+		return MandateExpired
+	case "failed":
+		return MandateFailed
+	}
+	return EventType("mandate." + status)
+}
+
+func replayKey(event *Event) string {
+	return strings.ToLower(event.Hash)
+}
@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	paytring "github.com/paytring/go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+var client = paytring.NewClient("your_key", "your_secret")
+
+// signedForm signs values, adding the current time as timestamp unless
+// values has one.
+func signedForm(values map[string]string) url.Values {
+	params := map[string]interface{}{"timestamp": strconv.FormatInt(time.Now().Unix(), 10)}
+	for k, v := range values {
+		params[k] = v
+	}
+	form := url.Values{}
+	for k, v := range client.MakeHash(params) {
+		form.Set(k, v.(string))
+	}
+	return form
+}
+
+func post(h http.Handler, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestOrderSuccessIsDispatched(t *testing.T) {
+	h := NewHandler(client)
+
+	var got *Event
+	h.OnOrderSuccess(func(ctx context.Context, e *Event) error {
+		got = e
+		return nil
+	})
+
+	w := post(h, signedForm(map[string]string{
+		"order_id":     "771606428862383868",
+		"receipt_id":   "TEST123",
+		"order_status": "success",
+		"amount":       "1000",
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, OrderSuccess, got.Type)
+		assert.Equal(t, "TEST123", got.ReceiptId)
+		assert.Equal(t, paytring.Amount(1000), got.Amount)
+	}
+}
+
+func TestRefundProcessedFromJSON(t *testing.T) {
+	h := NewHandler(client)
+
+	var got *Event
+	h.OnRefundProcessed(func(ctx context.Context, e *Event) error {
+		got = e
+		return nil
+	})
+
+	body, _ := json.Marshal(client.MakeHash(map[string]interface{}{
+		"timestamp":     time.Now().Unix(),
+		"order_id":      "771606428862383868",
+		"refund_id":     "R1",
+		"refund_status": "processed",
+		"attempt":       2,
+		"meta":          map[string]interface{}{"source": "dashboard"},
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, RefundProcessed, got.Type)
+		assert.Equal(t, "R1", got.RefundId)
+		assert.Equal(t, "R1", got.Payload["refund_id"])
+		assert.NotContains(t, got.Payload, "meta")
+		assert.NotContains(t, got.Payload, "attempt")
+		assert.Equal(t, map[string]interface{}{"source": "dashboard"}, got.Unverified["meta"])
+		assert.Equal(t, json.Number("2"), got.Unverified["attempt"])
+	}
+}
+
+func TestMandateEventsAreTyped(t *testing.T) {
+	h := NewHandler(client)
+
+	var got []*Event
+	record := func(ctx context.Context, e *Event) error {
+		got = append(got, e)
+		return nil
+	}
+	h.OnMandateActivated(record)
+	h.OnMandateDebitFailed(record)
+	h.OnMandateResumed(record)
+
+	assert.Equal(t, http.StatusOK, post(h, signedForm(map[string]string{
+		"mandate_id":     "M1",
+		"mandate_status": "active",
+	})).Code)
+	assert.Equal(t, http.StatusOK, post(h, signedForm(map[string]string{
+		"mandate_id":   "M1",
+		"order_id":     "771606428862383868",
+		"order_status": "failed",
+	})).Code)
+	assert.Equal(t, http.StatusOK, post(h, signedForm(map[string]string{
+		"event":          "mandate.resumed",
+		"mandate_id":     "M1",
+		"mandate_status": "active",
+	})).Code)
+	assert.Equal(t, http.StatusOK, post(h, signedForm(map[string]string{
+		"mandate_id":              "M1",
+		"mandate_status":          "active",
+		"previous_mandate_status": "paused",
+	})).Code)
+
+	if assert.Len(t, got, 4) {
+		assert.Equal(t, MandateActivated, got[0].Type)
+		assert.Equal(t, "M1", got[0].MandateId)
+		assert.Equal(t, "active", got[0].Status)
+		assert.Equal(t, MandateDebitFailed, got[1].Type)
+		assert.Equal(t, "failed", got[1].Status)
+		assert.Equal(t, MandateResumed, got[2].Type)
+		assert.Equal(t, MandateResumed, got[3].Type)
+		assert.Equal(t, "paused", got[3].PreviousStatus)
+	}
+}
+
+func TestTamperedPayloadIsRejected(t *testing.T) {
+	h := NewHandler(client)
+	h.OnOrderSuccess(func(ctx context.Context, e *Event) error {
+		t.Fatal("tampered payload dispatched")
+		return nil
+	})
+
+	form := signedForm(map[string]string{"order_id": "1", "order_status": "failed"})
+	form.Set("order_status", "success")
+	assert.Equal(t, http.StatusUnauthorized, post(h, form).Code)
+
+	form.Del("hash")
+	assert.Equal(t, http.StatusUnauthorized, post(h, form).Code)
+}
+
+func TestReplayedPayloadIsAcknowledged(t *testing.T) {
+	h := NewHandler(client)
+	calls := 0
+	h.OnOrderSuccess(func(ctx context.Context, e *Event) error {
+		calls++
+		return nil
+	})
+
+	form := signedForm(map[string]string{"order_id": "1", "order_status": "success"})
+	assert.Equal(t, http.StatusOK, post(h, form).Code)
+	assert.Equal(t, http.StatusOK, post(h, form).Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFailedDeliveryCanBeRetried(t *testing.T) {
+	h := NewHandler(client)
+	fail := true
+	h.OnOrderFailed(func(ctx context.Context, e *Event) error {
+		if fail {
+			fail = false
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	form := signedForm(map[string]string{"order_id": "1", "order_status": "failed"})
+	assert.Equal(t, http.StatusInternalServerError, post(h, form).Code)
+	assert.Equal(t, http.StatusOK, post(h, form).Code)
+}
+
+func TestStalePayloadIsRejected(t *testing.T) {
+	h := NewHandler(client)
+	h.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	old := strconv.FormatInt(time.Unix(1700000000, 0).Add(-time.Hour).Unix(), 10)
+	form := signedForm(map[string]string{"order_id": "1", "order_status": "success", "timestamp": old})
+	assert.Equal(t, http.StatusBadRequest, post(h, form).Code)
+
+	fresh := strconv.FormatInt(time.Unix(1700000000, 0).Add(-time.Minute).Unix(), 10)
+	form = signedForm(map[string]string{"order_id": "1", "order_status": "success", "timestamp": fresh})
+	assert.Equal(t, http.StatusOK, post(h, form).Code)
+}
+
+func TestPayloadWithoutTimestampIsRejected(t *testing.T) {
+	h := NewHandler(client)
+	params := client.MakeHash(map[string]interface{}{"order_id": "1", "order_status": "success"})
+	form := url.Values{}
+	for k, v := range params {
+		form.Set(k, v.(string))
+	}
+	assert.Equal(t, http.StatusBadRequest, post(h, form).Code)
+
+	h.Tolerance = 0
+	assert.Equal(t, http.StatusOK, post(h, form).Code)
+}
+
+func TestNumericTimestampIsChecked(t *testing.T) {
+	h := NewHandler(client)
+	h.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	send := func(ts int64) int {
+		body, _ := json.Marshal(client.MakeHash(map[string]interface{}{
+			"timestamp":    ts,
+			"order_id":     strconv.FormatInt(ts, 10),
+			"order_status": "success",
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(string(body)))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send(1700000000-3600))
+	assert.Equal(t, http.StatusOK, send(1700000000-60))
+}